@@ -10,6 +10,9 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -168,6 +171,13 @@ func (a *ExecAllocator) Allocate(ctx context.Context, opts ...BrowserOption) (*B
 			os.RemoveAll(dataDir)
 		}
 	}()
+	if removeDir {
+		// Only record the owner in the dirs created by the allocator,
+		// to leave the user's own dirs untouched.
+		if err := writeOwnerFile(dataDir); err != nil {
+			return nil, err
+		}
+	}
 	allocateCmdOptions(cmd)
 
 	stdout, err := cmd.StdoutPipe()
@@ -301,6 +311,91 @@ func (a *ExecAllocator) Wait() {
 	a.wg.Wait()
 }
 
+// CleanupOrphans kills the browser processes left behind by previous runs of
+// an ExecAllocator with the same configuration, such as when a test binary
+// panicked or was killed without cancelling its contexts. It returns the IDs of
+// the killed processes.
+//
+// A process is considered to be an orphan if it's the main process of a
+// browser using the allocator's user data dir, or any temporary user data dir
+// created by an allocator when none is set, and the process which allocated it
+// is gone. Allocators record their process ID in the temporary user data dirs
+// they create for that purpose, but never in a user data dir set via
+// UserDataDir; browsers whose user data dir doesn't have one are only
+// considered to be orphans once they have been reparented to the init process.
+// Browsers allocated by other running processes are never killed. Killing the main
+// process makes its helper processes exit too.
+//
+// The allocator of a context can be obtained via
+// FromContext(ctx).Allocator.(*ExecAllocator).
+//
+// Note: this is only supported on Linux.
+func (a *ExecAllocator) CleanupOrphans() ([]int, error) {
+	tempDir := allocTempDir
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	dataDirFlag := "--user-data-dir=" + filepath.Join(tempDir, "chromedp-runner")
+	isPrefix := true
+	if dir, ok := a.initFlags["user-data-dir"].(string); ok {
+		dataDirFlag, isPrefix = "--user-data-dir="+dir, false
+	}
+
+	pids, err := findOrphanProcesses(func(args []string) (string, bool) {
+		dataDir, matched := "", false
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "--type=") {
+				// A helper process, such as a renderer.
+				return "", false
+			}
+			if arg == dataDirFlag || (isPrefix && strings.HasPrefix(arg, dataDirFlag)) {
+				dataDir, matched = strings.TrimPrefix(arg, "--user-data-dir="), true
+			}
+		}
+		return dataDir, matched
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var killed []int
+	for _, pid := range pids {
+		p, err := os.FindProcess(pid)
+		if err == nil {
+			err = p.Kill()
+		}
+		if err != nil {
+			return killed, fmt.Errorf("could not kill process %d: %v", pid, err)
+		}
+		killed = append(killed, pid)
+	}
+	return killed, nil
+}
+
+// ownerFile is the name of the file in which an ExecAllocator records its
+// process ID in the temporary user data dir of the browsers it allocates, so
+// that CleanupOrphans can tell whether they were left behind.
+const ownerFile = "chromedp-owner"
+
+// writeOwnerFile records the ID of the current process in the user data dir.
+func writeOwnerFile(dataDir string) error {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dataDir, ownerFile), []byte(strconv.Itoa(os.Getpid())), 0600)
+}
+
+// readOwnerFile returns the ID of the process recorded in the user data dir,
+// if any.
+func readOwnerFile(dataDir string) (int, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(dataDir, ownerFile))
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil
+}
+
 // ExecPath returns an ExecAllocatorOption which uses the given path to execute
 // browser processes. The given path can be an absolute path to a binary, or
 // just the name of the program to find via exec.LookPath.
@@ -426,6 +521,21 @@ func DisableGPU(a *ExecAllocator) {
 	Flag("disable-gpu", true)(a)
 }
 
+// DisableInfobars is the command line option to disable the infobars and
+// prompts shown by Chrome in headful mode, such as the "Translate this page"
+// and "Save password" prompts, which shift the page's layout. The Translate
+// feature is added to the features already disabled via the
+// "disable-features" flag, so this option must be set after any such flag.
+func DisableInfobars(a *ExecAllocator) {
+	features := "Translate"
+	if v, ok := a.initFlags["disable-features"].(string); ok && v != "" {
+		features = v + "," + features
+	}
+	Flag("disable-features", features)(a)
+	Flag("disable-infobars", true)(a)
+	Flag("disable-save-password-bubble", true)(a)
+}
+
 // CombinedOutput is used to set an io.Writer where stdout and stderr
 // from the browser will be sent
 func CombinedOutput(w io.Writer) ExecAllocatorOption {
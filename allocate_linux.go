@@ -3,7 +3,13 @@
 package chromedp
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 )
 
@@ -14,3 +20,87 @@ func allocateCmdOptions(cmd *exec.Cmd) {
 	// When the parent process dies (Go), kill the child as well.
 	cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
 }
+
+// findOrphanProcesses returns the IDs of the orphan processes whose command
+// line arguments satisfy match, which also returns their user data dir. See
+// ExecAllocator.CleanupOrphans for what makes a process an orphan.
+func findOrphanProcesses(match func(args []string) (string, bool)) ([]int, error) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// The process may have exited meanwhile, or belong to another
+		// user; skip it in either case.
+		cmdline, err := ioutil.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		args := strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00")
+		dataDir, ok := match(args)
+		if !ok {
+			continue
+		}
+		_, ppid, err := processStat(pid)
+		if err != nil {
+			continue
+		}
+		if isOrphan(dataDir, ppid) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// isOrphan reports whether the browser using the user data dir, whose parent
+// is ppid, was left behind by the process which allocated it.
+func isOrphan(dataDir string, ppid int) bool {
+	owner, ok := readOwnerFile(dataDir)
+	if !ok {
+		// Without an owner, only a browser reparented to init is known
+		// to have lost its parent.
+		return ppid == 1
+	}
+	switch {
+	case owner == os.Getpid():
+		// Allocated by the current process.
+		return false
+	case !processAlive(owner):
+		return true
+	case ppid == 1 && owner != 1:
+		// Reparented to init, so the owner's ID was reused by
+		// another process.
+		return true
+	}
+	return false
+}
+
+// processAlive reports whether the process exists and hasn't exited yet.
+func processAlive(pid int) bool {
+	state, _, err := processStat(pid)
+	return err == nil && state != 'Z' && state != 'X'
+}
+
+// processStat returns the state of the process and the ID of its parent.
+func processStat(pid int) (byte, int, error) {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// The command name may contain spaces and parentheses, so skip past
+	// its closing parenthesis; then come the state and the parent ID.
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return 0, 0, fmt.Errorf("invalid stat for process %d", pid)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return fields[0][0], ppid, nil
+}
@@ -2,7 +2,14 @@
 
 package chromedp
 
-import "os/exec"
+import (
+	"errors"
+	"os/exec"
+)
 
 func allocateCmdOptions(cmd *exec.Cmd) {
 }
+
+func findOrphanProcesses(match func(args []string) (string, bool)) ([]int, error) {
+	return nil, errors.New("finding orphan processes is only supported on Linux")
+}
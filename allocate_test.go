@@ -9,7 +9,10 @@ import (
 	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -313,3 +316,140 @@ func TestEnv(t *testing.T) {
 		t.Fatalf("got %s, want %s", ret, tz)
 	}
 }
+
+func TestCleanupOrphans(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("orphan processes can only be found on Linux")
+	}
+
+	// Use a temporary user data dir like the ones created by allocators.
+	profile, err := ioutil.TempDir(allocTempDir, "chromedp-runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(profile)
+
+	// Start the browser from a shell which exits right away, recording
+	// the shell as the browser's owner, like after a crashed run.
+	cmd, pid := startShellBrowser(t, profile, "", true)
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := NewExecAllocator(context.Background(), allocOpts...)
+	defer cancel()
+
+	pids, err := FromContext(allocCtx).Allocator.(*ExecAllocator).CleanupOrphans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pids) != 1 || pids[0] != pid {
+		t.Fatalf("expected the orphan %d to be killed, got: %v", pid, pids)
+	}
+
+	// Wait for the process to be gone.
+	for i := 0; ; i++ {
+		if _, err := os.Stat(fmt.Sprintf("/proc/%d/cmdline", pid)); os.IsNotExist(err) {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("expected the orphan %d to exit", pid)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestCleanupOrphansRunning(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("orphan processes can only be found on Linux")
+	}
+
+	tempDir, err := ioutil.TempDir("", "chromedp-orphan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Start the browser from a shell which keeps running, like another
+	// process using an allocator with the same configuration. No owner
+	// is recorded in the user's own data dirs.
+	profile := filepath.Join(tempDir, "profile")
+	cmd, pid := startShellBrowser(t, profile, "wait", false)
+	defer func() {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+		cmd.Wait()
+	}()
+
+	allocCtx, cancel := NewExecAllocator(context.Background(),
+		append(allocOpts, UserDataDir(profile))...)
+	defer cancel()
+
+	pids, err := FromContext(allocCtx).Allocator.(*ExecAllocator).CleanupOrphans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pids) != 0 {
+		t.Fatalf("expected no processes to be killed, got: %v", pids)
+	}
+	if _, err := os.Stat(fmt.Sprintf("/proc/%d/cmdline", pid)); err != nil {
+		t.Fatalf("expected the browser %d to keep running: %v", pid, err)
+	}
+}
+
+// startShellBrowser starts a browser using the profile dir in the background
+// of a shell, which then runs the given command, and optionally records the
+// shell as the browser's owner. It returns the shell and the ID of the browser
+// process.
+func startShellBrowser(t *testing.T, profile, then string, recordOwner bool) (*exec.Cmd, int) {
+	t.Helper()
+
+	pidFile := profile + ".pid"
+	cmd := exec.Command("sh", "-c", fmt.Sprintf(`%q --headless --disable-gpu --no-sandbox --user-data-dir=%q about:blank >/dev/null 2>&1 & echo $! > %q; %s`,
+		execPath, profile, pidFile, then))
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if recordOwner {
+		if err := os.MkdirAll(profile, 0700); err != nil {
+			t.Fatal(err)
+		}
+		owner := strconv.Itoa(cmd.Process.Pid)
+		if err := ioutil.WriteFile(filepath.Join(profile, ownerFile), []byte(owner), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; ; i++ {
+		out, err := ioutil.ReadFile(pidFile)
+		if pid, err2 := strconv.Atoi(strings.TrimSpace(string(out))); err == nil && err2 == nil {
+			os.Remove(pidFile)
+			return cmd, pid
+		}
+		if i == 50 {
+			t.Fatal("expected the browser to start")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestDisableInfobars(t *testing.T) {
+	t.Parallel()
+
+	opts := append(DefaultExecAllocatorOptions[:], DisableInfobars)
+	a := setupExecAllocator(opts...)
+	want := "site-per-process,TranslateUI,BlinkGenPropertyTrees,Translate"
+	if got := a.initFlags["disable-features"]; got != want {
+		t.Errorf("expected disable-features to be %q, got: %v", want, got)
+	}
+	for _, name := range []string{"disable-infobars", "disable-save-password-bubble"} {
+		if got := a.initFlags[name]; got != true {
+			t.Errorf("expected %s to be set, got: %v", name, got)
+		}
+	}
+}
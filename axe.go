@@ -0,0 +1,105 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+)
+
+// AxeResults are the results of an axe-core accessibility audit.
+type AxeResults struct {
+	// Violations are the rules which failed.
+	Violations []*AxeRule `json:"violations"`
+
+	// Incomplete are the rules which could not be checked automatically,
+	// and need a manual review.
+	Incomplete []*AxeRule `json:"incomplete"`
+}
+
+// AxeRule is the result of an axe-core rule.
+type AxeRule struct {
+	ID          string     `json:"id"`
+	Impact      string     `json:"impact"`
+	Description string     `json:"description"`
+	Help        string     `json:"help"`
+	HelpURL     string     `json:"helpUrl"`
+	Tags        []string   `json:"tags"`
+	Nodes       []*AxeNode `json:"nodes"`
+}
+
+// AxeNode is an element checked by an axe-core rule.
+type AxeNode struct {
+	// HTML is the outer HTML of the element.
+	HTML string `json:"html"`
+
+	// Target is the CSS selector of the element. Elements within iframes
+	// or shadow trees have one selector per level.
+	Target []interface{} `json:"target"`
+
+	Impact         string `json:"impact"`
+	FailureSummary string `json:"failureSummary"`
+}
+
+// InjectAxe is an action that injects the provided axe-core script into the
+// current document, unless axe-core is already loaded. It must be run before
+// RunAxe, with the contents of a copy of axe.min.js which the caller trusts,
+// such as one bundled with the tests.
+func InjectAxe(src string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		var loaded bool
+		if err := Evaluate(`typeof axe !== 'undefined'`, &loaded).Do(ctx); err != nil {
+			return err
+		}
+		if loaded {
+			return nil
+		}
+		return Evaluate(src, &[]byte{}).Do(ctx)
+	})
+}
+
+// RunAxe is an element query action that audits the accessibility of the first
+// element node matching the selector, and its descendants, using the axe-core
+// library, storing the results in res.
+//
+// axe-core isn't downloaded on demand; the action returns ErrAxeNotLoaded if
+// it wasn't loaded in the current document beforehand, such as via InjectAxe.
+func RunAxe(sel interface{}, res *AxeResults, opts ...QueryOption) QueryAction {
+	return RunAxeTags(sel, nil, res, opts...)
+}
+
+// RunAxeTags is an element query action like RunAxe, but only running the
+// rules with any of the tags, such as "wcag2aa" or "best-practice".
+func RunAxeTags(sel interface{}, tags []string, res *AxeResults, opts ...QueryOption) QueryAction {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var loaded bool
+		if err := Evaluate(`typeof axe !== 'undefined'`, &loaded).Do(ctx); err != nil {
+			return err
+		}
+		if !loaded {
+			return ErrAxeNotLoaded
+		}
+
+		options := map[string]interface{}{}
+		if len(tags) > 0 {
+			options["runOnly"] = map[string]interface{}{
+				"type":   "tag",
+				"values": tags,
+			}
+		}
+		buf, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		return EvaluateAsDevTools(snippet(axeRunJS, cashX(true), sel, nodes[0], string(buf)), res, evalAwaitPromise).Do(ctx)
+	}, opts...)
+}
@@ -0,0 +1,64 @@
+package chromedp
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// axeStubJS is a minimal stand-in for axe-core, reporting every image without
+// an alt attribute within the audited element as a violation.
+const axeStubJS = `window.axe = {
+	run: function(context, options) {
+		var nodes = [];
+		context.querySelectorAll('img:not([alt])').forEach(function(img) {
+			nodes.push({html: img.outerHTML, target: ['#' + img.id], impact: 'critical'});
+		});
+		return Promise.resolve({
+			violations: nodes.length ? [{id: 'image-alt', impact: 'critical', tags: (options.runOnly || {}).values || [], nodes: nodes}] : [],
+			incomplete: []
+		});
+	}
+};`
+
+func TestRunAxe(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<div id="ok"><img id="logo" alt="logo"></div>
+<div id="bad"><img id="banner"></div>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var ok, bad AxeResults
+	if err := Run(ctx,
+		Navigate(s.URL),
+		RunAxe(`#ok`, &ok, ByID),
+	); err != ErrAxeNotLoaded {
+		t.Fatalf("expected ErrAxeNotLoaded before injecting axe-core, got: %v", err)
+	}
+	if err := Run(ctx,
+		InjectAxe(axeStubJS),
+		RunAxe(`#ok`, &ok, ByID),
+		RunAxeTags(`#bad`, []string{"wcag2a"}, &bad, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(ok.Violations) != 0 {
+		t.Errorf("expected no violations, got: %d", len(ok.Violations))
+	}
+	if len(bad.Violations) != 1 {
+		t.Fatalf("expected 1 violation, got: %d", len(bad.Violations))
+	}
+	rule := bad.Violations[0]
+	if rule.ID != "image-alt" || !reflect.DeepEqual(rule.Tags, []string{"wcag2a"}) {
+		t.Errorf("unexpected violation: %+v", rule)
+	}
+	if len(rule.Nodes) != 1 || rule.Nodes[0].HTML != `<img id="banner">` ||
+		!reflect.DeepEqual(rule.Nodes[0].Target, []interface{}{"#banner"}) {
+		t.Errorf("unexpected violating nodes: %+v", rule.Nodes)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/target"
 )
 
@@ -124,7 +125,9 @@ func (b *Browser) newExecutorForTarget(ctx context.Context, targetID target.ID,
 
 		messageQueue: make(chan *cdproto.Message, 1024),
 		frames:       make(map[cdp.FrameID]*cdp.Frame),
+		requests:     make(map[network.RequestID]*trackedRequest),
 
+		ctx:  ctx,
 		logf: b.logf,
 		errf: b.errf,
 	}
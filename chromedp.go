@@ -46,6 +46,9 @@ type Context struct {
 	// unused page target, or create a new one.
 	targetID target.ID
 
+	// failOnConsoleError is set up by FailOnConsoleError.
+	failOnConsoleError bool
+
 	browserListeners []cancelableListener
 	targetListeners  []cancelableListener
 
@@ -237,9 +240,55 @@ func Run(ctx context.Context, actions ...Action) error {
 			return err
 		}
 	}
+	if c.failOnConsoleError {
+		return runFailOnConsoleError(cdp.WithExecutor(ctx, c.Target), actions)
+	}
 	return Tasks(actions).Do(cdp.WithExecutor(ctx, c.Target))
 }
 
+// runFailOnConsoleError runs the actions, returning a ConsoleErrors error if
+// the actions succeed but any console errors or uncaught exceptions were
+// received meanwhile.
+func runFailOnConsoleError(ctx context.Context, actions []Action) error {
+	var mu sync.Mutex
+	var errs ConsoleErrors
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ListenTarget(lctx, func(ev interface{}) {
+		var msg string
+		switch ev := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			if ev.Type != runtime.APITypeError {
+				return
+			}
+			args := make([]string, len(ev.Args))
+			for i, arg := range ev.Args {
+				args[i] = remoteObjectString(arg)
+			}
+			msg = strings.Join(args, " ")
+		case *runtime.EventExceptionThrown:
+			msg = exceptionString(ev.ExceptionDetails)
+		default:
+			return
+		}
+		mu.Lock()
+		errs = append(errs, msg)
+		mu.Unlock()
+	})
+
+	if err := Tasks(actions).Do(ctx); err != nil {
+		return err
+	}
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 func (c *Context) newTarget(ctx context.Context) error {
 	if c.targetID != "" {
 		if err := c.attachTarget(ctx, c.targetID); err != nil {
@@ -365,6 +414,16 @@ func WithTargetID(id target.ID) ContextOption {
 	return func(c *Context) { c.targetID = id }
 }
 
+// FailOnConsoleError is a context option that makes Run return a ConsoleErrors
+// error if any console.error calls or uncaught exceptions happen on the target
+// while running the actions.
+//
+// Note that events are received asynchronously, so errors happening right
+// before the last action finishes might not be reported.
+func FailOnConsoleError(c *Context) {
+	c.failOnConsoleError = true
+}
+
 // WithLogf is a shortcut for WithBrowserOption(WithBrowserLogf(f)).
 func WithLogf(f func(string, ...interface{})) ContextOption {
 	return WithBrowserOption(WithBrowserLogf(f))
@@ -401,6 +460,76 @@ func Targets(ctx context.Context) ([]*target.Info, error) {
 	return target.GetTargets().Do(cdp.WithExecutor(ctx, c.Browser))
 }
 
+// BrowserAction wraps the action so that it's executed against the browser
+// session of the context, rather than its target. It's useful for commands of
+// browser-level domains, such as browser.GetVersion or systeminfo.GetInfo,
+// which page targets don't handle.
+func BrowserAction(action Action) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		c := FromContext(ctx)
+		if c == nil || c.Browser == nil {
+			return ErrInvalidContext
+		}
+		return action.Do(cdp.WithExecutor(ctx, c.Browser))
+	})
+}
+
+// VersionInfo holds the version information of a browser.
+type VersionInfo struct {
+	// Product is the product name and version, such as "HeadlessChrome/79.0.3945.0".
+	Product string
+
+	// Revision is the product revision.
+	Revision string
+
+	// JSVersion is the V8 version.
+	JSVersion string
+
+	// UserAgent is the default user agent.
+	UserAgent string
+
+	// ProtocolVersion is the DevTools protocol version.
+	ProtocolVersion string
+}
+
+// Version is an action that retrieves the version information of the browser.
+func Version(info *VersionInfo) Action {
+	if info == nil {
+		panic("info cannot be nil")
+	}
+	return BrowserAction(ActionFunc(func(ctx context.Context) error {
+		protocolVersion, product, revision, userAgent, jsVersion, err := browser.GetVersion().Do(ctx)
+		if err != nil {
+			return err
+		}
+		*info = VersionInfo{
+			Product:         product,
+			Revision:        revision,
+			JSVersion:       jsVersion,
+			UserAgent:       userAgent,
+			ProtocolVersion: protocolVersion,
+		}
+		return nil
+	}))
+}
+
+// BrowserCommandLine is an action that retrieves the command line switches the
+// browser process was started with, such as "--headless".
+//
+// Useful for checking which flags are in effect, such as when the browser
+// wasn't started by an ExecAllocator. The browser must have been started with
+// the "enable-automation" flag, which ExecAllocator sets by default.
+func BrowserCommandLine(args *[]string) Action {
+	if args == nil {
+		panic("args cannot be nil")
+	}
+	return BrowserAction(ActionFunc(func(ctx context.Context) error {
+		var err error
+		*args, err = browser.GetBrowserCommandLine().Do(ctx)
+		return err
+	}))
+}
+
 // Action is the common interface for an action that will be executed against a
 // context and frame handler.
 type Action interface {
@@ -430,6 +559,91 @@ func (t Tasks) Do(ctx context.Context) error {
 	return nil
 }
 
+// Transaction is an action that runs the actions sequentially like Tasks, but
+// collects diagnostics when one of them fails: a screenshot of the viewport,
+// the HTML of the document, and the messages logged to the console and the
+// uncaught exceptions thrown since the transaction started. They are returned
+// along with the failure as a *TransactionError.
+//
+// Diagnostics are collected on a best effort basis, even if the failure was
+// caused by the context being cancelled or timing out, as long as the target
+// is still alive; those which can't be collected are left empty.
+func Transaction(actions ...Action) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		var mu sync.Mutex
+		var logs []string
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			var msg string
+			switch e := ev.(type) {
+			case *runtime.EventConsoleAPICalled:
+				args := make([]string, len(e.Args))
+				for i, arg := range e.Args {
+					args[i] = remoteObjectString(arg)
+				}
+				msg = fmt.Sprintf("%s: %s", e.Type, strings.Join(args, " "))
+			case *runtime.EventExceptionThrown:
+				msg = "exception: " + exceptionString(e.ExceptionDetails)
+			default:
+				return
+			}
+			mu.Lock()
+			logs = append(logs, msg)
+			mu.Unlock()
+		})
+
+		for i, action := range actions {
+			err := action.Do(ctx)
+			if err == nil {
+				continue
+			}
+			terr := &TransactionError{Index: i, Err: err}
+
+			// ctx may be done, so use a new one with the same executor.
+			dctx, dcancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer dcancel()
+			dctx = cdp.WithExecutor(dctx, cdp.ExecutorFromContext(ctx))
+			_ = CaptureScreenshot(&terr.Screenshot).Do(dctx)
+			_ = Evaluate(`document.documentElement ? document.documentElement.outerHTML : ''`, &terr.HTML).Do(dctx)
+
+			cancel()
+			mu.Lock()
+			terr.Logs = logs
+			mu.Unlock()
+			return terr
+		}
+		return nil
+	})
+}
+
+// Timed is an action that runs the action, storing how long it took to
+// complete in dur, as measured by the wall clock. dur is set even when the
+// action fails, or when ctx is cancelled.
+//
+// Useful to find out which steps of a flow are slow, by wrapping each of them:
+//
+//	var nav, login time.Duration
+//	err := chromedp.Run(ctx,
+//		chromedp.Timed(chromedp.Navigate(url), &nav),
+//		chromedp.Timed(chromedp.Click("#login", chromedp.ByQuery), &login),
+//	)
+func Timed(action Action, dur *time.Duration) Action {
+	if action == nil {
+		panic("action cannot be nil")
+	}
+	if dur == nil {
+		panic("dur cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		start := time.Now()
+		err := action.Do(ctx)
+		*dur = time.Since(start)
+		return err
+	})
+}
+
 // Sleep is an empty action that calls time.Sleep with the specified duration.
 //
 // Note: this is a temporary action definition for convenience, and will likely
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"image/png"
 	"io"
 	"io/ioutil"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -569,6 +571,39 @@ func TestLogOptions(t *testing.T) {
 	}
 }
 
+func TestFailOnConsoleError(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/ok", writeHTML(`<script>console.log('all good');</script>`))
+	mux.Handle("/error", writeHTML(`<script>
+	console.error('boom', 42);
+	throw new Error('uncaught');
+</script>`))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx1, cancel1 := testAllocate(t, "")
+	defer cancel1()
+
+	ctx, cancel := NewContext(ctx1, FailOnConsoleError)
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL+"/ok")); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	err := Run(ctx, Navigate(s.URL+"/error"))
+	errs, ok := err.(ConsoleErrors)
+	if !ok {
+		t.Fatalf("expected a ConsoleErrors error, got: %v", err)
+	}
+	want := ConsoleErrors{"boom 42", "Uncaught Error: uncaught"}
+	if !reflect.DeepEqual(errs, want) {
+		t.Fatalf("expected %q, got: %q", want, errs)
+	}
+}
+
 func TestLargeOutboundMessages(t *testing.T) {
 	t.Parallel()
 
@@ -781,3 +816,127 @@ func TestAttachingToWorkers(t *testing.T) {
 		})
 	}
 }
+
+func TestBrowserAction(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var product string
+	if err := Run(ctx, BrowserAction(ActionFunc(func(ctx context.Context) error {
+		_, prod, _, _, _, err := browser.GetVersion().Do(ctx)
+		product = prod
+		return err
+	}))); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(product, "Chrom") {
+		t.Errorf("expected a Chrome product, got: %q", product)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var info VersionInfo
+	var userAgent string
+	if err := Run(ctx,
+		Version(&info),
+		Evaluate(`navigator.userAgent`, &userAgent),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(info.Product, "Chrom") {
+		t.Errorf("expected a Chrome product, got: %q", info.Product)
+	}
+	if info.ProtocolVersion == "" || info.JSVersion == "" || info.Revision == "" {
+		t.Errorf("expected all versions to be set, got: %+v", info)
+	}
+	if info.UserAgent != userAgent {
+		t.Errorf("expected user agent %q, got: %q", userAgent, info.UserAgent)
+	}
+}
+
+func TestBrowserCommandLine(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var args []string
+	if err := Run(ctx, BrowserCommandLine(&args)); err != nil {
+		t.Fatal(err)
+	}
+	var automation bool
+	for _, arg := range args {
+		if arg == "--enable-automation" {
+			automation = true
+		}
+	}
+	if !automation {
+		t.Errorf("expected the --enable-automation flag, got: %q", args)
+	}
+}
+
+func TestTimed(t *testing.T) {
+	t.Parallel()
+
+	var slept time.Duration
+	if err := Timed(Sleep(50*time.Millisecond), &slept).Do(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if slept < 50*time.Millisecond {
+		t.Errorf("expected at least 50ms, got: %v", slept)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var cancelled time.Duration
+	if err := Timed(Sleep(time.Minute), &cancelled).Do(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if cancelled < 20*time.Millisecond || cancelled >= time.Minute {
+		t.Errorf("expected the time until ctx was done, got: %v", cancelled)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	action := Transaction(
+		Evaluate(`console.log('started', 1); document.body.innerHTML = '<p id="step">step 1</p>';`, &[]byte{}),
+		Evaluate(`setTimeout(function() { throw new Error('boom'); }, 0);`, &[]byte{}),
+		Sleep(100*time.Millisecond),
+		Evaluate(`missing()`, &[]byte{}),
+		Evaluate(`console.log('not reached')`, &[]byte{}),
+	)
+	err := Run(ctx, action)
+	terr, ok := err.(*TransactionError)
+	if !ok {
+		t.Fatalf("expected a *TransactionError, got: %v", err)
+	}
+	if terr.Index != 3 || terr.Err == nil {
+		t.Errorf("expected action 3 to fail, got: %v", terr)
+	}
+	if !strings.Contains(terr.HTML, `<p id="step">step 1</p>`) {
+		t.Errorf("expected the document HTML, got: %q", terr.HTML)
+	}
+	if _, err := png.Decode(bytes.NewReader(terr.Screenshot)); err != nil {
+		t.Errorf("expected a PNG screenshot, got: %v", err)
+	}
+	if len(terr.Logs) < 2 || terr.Logs[0] != "log: started 1" ||
+		!strings.HasPrefix(terr.Logs[1], "exception: ") || !strings.Contains(terr.Logs[1], "boom") {
+		t.Errorf("unexpected logs: %q", terr.Logs)
+	}
+
+	if err := Run(ctx, Transaction(Evaluate(`1`, &[]byte{}))); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/domdebugger"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// EventListeners is an element query action that retrieves the event
+// listeners registered on the first element node matching the selector. Only
+// the listeners of the node itself are retrieved, not those of its ancestors
+// which may handle the events as they bubble up.
+func EventListeners(sel interface{}, listeners *[]*domdebugger.EventListener, opts ...QueryOption) QueryAction {
+	if listeners == nil {
+		panic("listeners cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		obj, err := dom.ResolveNode().WithNodeID(nodes[0].NodeID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+
+		*listeners, err = domdebugger.GetEventListeners(obj.ObjectID).Do(ctx)
+		return err
+	}, opts...)
+}
+
+// SetDOMBreakpoint is an element query action that enables the Debugger
+// domain, and then sets a breakpoint of the specified type on the first
+// element node matching the selector, pausing the page's Javascript execution
+// whenever its subtree or attributes are modified, or it is removed.
+//
+// A debugger.EventPaused event with the "DOM" reason is sent once paused, and
+// execution stays paused until debugger.Resume is run; see ListenTarget.
+func SetDOMBreakpoint(sel interface{}, typ domdebugger.DOMBreakpointType, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		if _, err := debugger.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return domdebugger.SetDOMBreakpoint(nodes[0].NodeID, typ).Do(ctx)
+	}, opts...)
+}
+
+// RemoveDOMBreakpoint is an element query action that removes the breakpoint of
+// the specified type from the first element node matching the selector, as set
+// by SetDOMBreakpoint.
+func RemoveDOMBreakpoint(sel interface{}, typ domdebugger.DOMBreakpointType, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return domdebugger.RemoveDOMBreakpoint(nodes[0].NodeID, typ).Do(ctx)
+	}, opts...)
+}
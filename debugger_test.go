@@ -0,0 +1,97 @@
+package chromedp
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/domdebugger"
+)
+
+func TestEventListeners(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<button id="btn">click</button>
+<script>
+	var btn = document.getElementById('btn');
+	btn.addEventListener('click', function() {});
+	btn.addEventListener('keydown', function() {}, true);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var listeners []*domdebugger.EventListener
+	if err := Run(ctx,
+		Navigate(s.URL),
+		EventListeners(`#btn`, &listeners, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]bool)
+	for _, l := range listeners {
+		types[l.Type] = l.UseCapture
+	}
+	if len(types) != 2 {
+		t.Fatalf("expected 2 listeners, got: %d", len(listeners))
+	}
+	if capture, ok := types["click"]; !ok || capture {
+		t.Errorf("expected a bubbling click listener, got: %v", types)
+	}
+	if capture, ok := types["keydown"]; !ok || !capture {
+		t.Errorf("expected a capturing keydown listener, got: %v", types)
+	}
+}
+
+func TestSetDOMBreakpoint(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<ul id="list"></ul>
+<script>
+	function addItem() {
+		document.getElementById('list').appendChild(document.createElement('li'));
+	}
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	paused := make(chan debugger.PausedReason, 1)
+	ListenTarget(ctx, func(ev interface{}) {
+		if e, ok := ev.(*debugger.EventPaused); ok {
+			paused <- e.Reason
+			go Run(ctx, debugger.Resume())
+		}
+	})
+
+	var res []byte
+	if err := Run(ctx,
+		Navigate(s.URL),
+		SetDOMBreakpoint(`#list`, domdebugger.DOMBreakpointTypeSubtreeModified, ByID),
+		Evaluate(`addItem()`, &res),
+	); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case reason := <-paused:
+		if reason != debugger.PausedReasonDOM {
+			t.Errorf("expected to pause on a DOM breakpoint, got: %q", reason)
+		}
+	default:
+		t.Fatal("expected execution to pause")
+	}
+
+	if err := Run(ctx,
+		RemoveDOMBreakpoint(`#list`, domdebugger.DOMBreakpointTypeSubtreeModified, ByID),
+		Evaluate(`addItem()`, &res),
+	); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-paused:
+		t.Error("expected execution not to pause after removing the breakpoint")
+	default:
+	}
+}
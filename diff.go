@@ -0,0 +1,469 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// DiffResult is the result of comparing a screenshot against a baseline.
+type DiffResult struct {
+	// DiffPixels is the number of pixels which differ.
+	DiffPixels int
+
+	// TotalPixels is the number of pixels compared.
+	TotalPixels int
+
+	// Percent is the percentage of pixels which differ.
+	Percent float64
+
+	// Image is a PNG image of the baseline, faded out, with the differing
+	// pixels highlighted in red and the anti-aliased pixels in yellow. It is
+	// only set when the DiffWithImage option is used.
+	Image []byte
+}
+
+// ScreenshotDiff is an action that captures a screenshot of the current
+// browser viewport to current, and compares it pixel by pixel against the
+// baseline PNG image, storing the result of the comparison in diff.
+//
+// Pixels are compared in the YIQ color space, so that the tolerated difference
+// matches the perceived one; see DiffThreshold. Pixels which only differ due to
+// anti-aliasing are not counted, unless the DiffIncludeAntialiasing option is
+// used.
+func ScreenshotDiff(baseline []byte, current *[]byte, diff *DiffResult, opts ...DiffOption) Action {
+	if current == nil {
+		panic("current cannot be nil")
+	}
+	if diff == nil {
+		panic("diff cannot be nil")
+	}
+
+	o := &diffOptions{threshold: 0.1}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		base, err := png.Decode(bytes.NewReader(baseline))
+		if err != nil {
+			return fmt.Errorf("could not decode baseline: %v", err)
+		}
+		if err := CaptureScreenshot(current).Do(ctx); err != nil {
+			return err
+		}
+		img, err := png.Decode(bytes.NewReader(*current))
+		if err != nil {
+			return err
+		}
+
+		res, err := diffImages(base, img, o)
+		if err != nil {
+			return err
+		}
+		*diff = *res
+		return nil
+	})
+}
+
+// DeltaScreenshot is an action that captures a screenshot of the current
+// browser viewport, and compares it against the previous one in prev, storing
+// the smallest region containing all the changed pixels as a PNG image in res,
+// and its location in bounds. prev is then replaced by the new screenshot, so
+// that the action can be run repeatedly to stream the changes of the page.
+//
+// When prev is empty or of a different size, the entire screenshot is
+// considered changed. When nothing changed, res is set to nil and bounds to
+// the empty rectangle.
+func DeltaScreenshot(prev *[]byte, res *[]byte, bounds *image.Rectangle) Action {
+	if prev == nil {
+		panic("prev cannot be nil")
+	}
+	if res == nil {
+		panic("res cannot be nil")
+	}
+	if bounds == nil {
+		panic("bounds cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var buf []byte
+		if err := CaptureScreenshot(&buf).Do(ctx); err != nil {
+			return err
+		}
+		img, err := png.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return err
+		}
+
+		changed := img.Bounds()
+		if len(*prev) > 0 {
+			old, err := png.Decode(bytes.NewReader(*prev))
+			if err != nil {
+				return fmt.Errorf("could not decode previous screenshot: %v", err)
+			}
+			if old.Bounds() == img.Bounds() {
+				changed = changedBounds(newPixels(old), newPixels(img))
+			}
+		}
+
+		*prev = buf
+		*bounds = changed
+		switch {
+		case changed.Empty():
+			*res = nil
+		case changed == img.Bounds():
+			*res = buf
+		default:
+			sub := img.(interface {
+				SubImage(image.Rectangle) image.Image
+			}).SubImage(changed)
+			var out bytes.Buffer
+			if err := png.Encode(&out, sub); err != nil {
+				return err
+			}
+			*res = out.Bytes()
+		}
+		return nil
+	})
+}
+
+// changedBounds returns the smallest rectangle containing all the pixels which
+// differ between the two images of the same size.
+func changedBounds(p1, p2 *pixels) image.Rectangle {
+	var r image.Rectangle
+	for y := 0; y < p1.h; y++ {
+		for x := 0; x < p1.w; x++ {
+			if p1.at(x, y) != p2.at(x, y) {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+type diffOptions struct {
+	threshold           float64
+	includeAntialiasing bool
+	image               bool
+}
+
+// DiffOption is a ScreenshotDiff action option.
+type DiffOption = func(*diffOptions)
+
+// DiffThreshold is a ScreenshotDiff action option to set the tolerated color
+// difference between two pixels, from 0 (exact match) to 1. Defaults to 0.1.
+func DiffThreshold(threshold float64) DiffOption {
+	return func(o *diffOptions) {
+		o.threshold = threshold
+	}
+}
+
+// DiffIncludeAntialiasing is a ScreenshotDiff action option to count the
+// pixels which only differ due to anti-aliasing.
+func DiffIncludeAntialiasing(o *diffOptions) {
+	o.includeAntialiasing = true
+}
+
+// DiffWithImage is a ScreenshotDiff action option to generate a diff image.
+func DiffWithImage(o *diffOptions) {
+	o.image = true
+}
+
+// PageHash is an action that computes a hash of the rendered page of the
+// current target, storing it in hash as a hex-encoded SHA-256 sum, as a cheap
+// signal for detecting changes across runs before doing a full comparison.
+//
+// By default, the normalized structure of the DOM is hashed: comments, scripts
+// and whitespace-only text are left out, whitespace is collapsed, attributes
+// are sorted, and volatile attributes such as nonce are removed; see
+// HashIgnoreAttributes. With the HashScreenshot option, a downscaled
+// screenshot of the viewport is hashed instead.
+func PageHash(hash *string, opts ...HashOption) Action {
+	if hash == nil {
+		panic("hash cannot be nil")
+	}
+
+	o := &hashOptions{ignore: []string{"nonce"}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		h := sha256.New()
+		if o.screenshot {
+			var buf []byte
+			if err := CaptureScreenshot(&buf).Do(ctx); err != nil {
+				return err
+			}
+			img, err := png.Decode(bytes.NewReader(buf))
+			if err != nil {
+				return err
+			}
+			h.Write(downscaledGray(newPixels(img), hashSize))
+		} else {
+			ignore, err := json.Marshal(o.ignore)
+			if err != nil {
+				return err
+			}
+			var dom string
+			if err := Evaluate(fmt.Sprintf(normalizedDOMJS, ignore), &dom).Do(ctx); err != nil {
+				return err
+			}
+			h.Write([]byte(dom))
+		}
+		*hash = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+}
+
+// hashSize is the width and height to which screenshots are downscaled by
+// PageHash.
+const hashSize = 64
+
+// downscaledGray returns the pixels averaged over a grid of size by size
+// cells, converted to grayscale and quantized to 16 levels, so that small
+// rendering differences don't affect the result.
+func downscaledGray(p *pixels, size int) []byte {
+	out := make([]byte, 0, size*size)
+	for cy := 0; cy < size; cy++ {
+		y0, y1 := cy*p.h/size, (cy+1)*p.h/size
+		if y1 == y0 && y0 < p.h {
+			y1++
+		}
+		for cx := 0; cx < size; cx++ {
+			x0, x1 := cx*p.w/size, (cx+1)*p.w/size
+			if x1 == x0 && x0 < p.w {
+				x1++
+			}
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					c := p.at(x, y)
+					sum += 0.299*c[0] + 0.587*c[1] + 0.114*c[2]
+				}
+			}
+			var gray byte
+			if n := (x1 - x0) * (y1 - y0); n > 0 {
+				gray = byte(sum/float64(n)) >> 4
+			}
+			out = append(out, gray)
+		}
+	}
+	return out
+}
+
+// hashOptions holds the options of PageHash.
+type hashOptions struct {
+	screenshot bool
+	ignore     []string
+}
+
+// HashOption is a PageHash action option.
+type HashOption = func(*hashOptions)
+
+// HashScreenshot is a PageHash action option to hash a screenshot of the
+// viewport, downscaled to 64x64 pixels in 16 levels of gray, instead of the
+// DOM.
+func HashScreenshot(o *hashOptions) {
+	o.screenshot = true
+}
+
+// HashIgnoreAttributes is a PageHash action option to leave out the named
+// attributes, such as the ones holding generated ids or CSRF tokens, when
+// hashing the DOM. The nonce attribute is always left out.
+func HashIgnoreAttributes(names ...string) HashOption {
+	return func(o *hashOptions) {
+		o.ignore = append(o.ignore, names...)
+	}
+}
+
+// diffImages compares the two images pixel by pixel, using the approach of the
+// pixelmatch library to tolerate perceptually small color differences and to
+// detect anti-aliased pixels.
+func diffImages(a, b image.Image, o *diffOptions) (*DiffResult, error) {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return nil, fmt.Errorf("baseline is %dx%d, but screenshot is %dx%d", ab.Dx(), ab.Dy(), bb.Dx(), bb.Dy())
+	}
+	p1, p2 := newPixels(a), newPixels(b)
+
+	var out *image.NRGBA
+	if o.image {
+		out = image.NewNRGBA(image.Rect(0, 0, p1.w, p1.h))
+	}
+
+	// The maximum acceptable squared YIQ distance between two colors.
+	maxDelta := 35215 * o.threshold * o.threshold
+	var diff int
+	for y := 0; y < p1.h; y++ {
+		for x := 0; x < p1.w; x++ {
+			delta := colorDelta(p1.at(x, y), p2.at(x, y), false)
+			switch {
+			case delta <= maxDelta:
+				if out != nil {
+					v := uint8(255 - 0.1*(255-yiqY(p1.at(x, y))))
+					out.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+				}
+			case !o.includeAntialiasing && (antialiased(p1, p2, x, y) || antialiased(p2, p1, x, y)):
+				if out != nil {
+					out.SetNRGBA(x, y, color.NRGBA{255, 255, 0, 255})
+				}
+			default:
+				diff++
+				if out != nil {
+					out.SetNRGBA(x, y, color.NRGBA{255, 0, 0, 255})
+				}
+			}
+		}
+	}
+
+	res := &DiffResult{
+		DiffPixels:  diff,
+		TotalPixels: p1.w * p1.h,
+	}
+	if res.TotalPixels > 0 {
+		res.Percent = 100 * float64(diff) / float64(res.TotalPixels)
+	}
+	if out != nil {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, out); err != nil {
+			return nil, err
+		}
+		res.Image = buf.Bytes()
+	}
+	return res, nil
+}
+
+// pixels holds the colors of an image, blended onto a white background.
+type pixels struct {
+	w, h int
+	c    [][3]float64
+}
+
+// newPixels returns the pixels of the image.
+func newPixels(img image.Image) *pixels {
+	b := img.Bounds()
+	p := &pixels{w: b.Dx(), h: b.Dy(), c: make([][3]float64, b.Dx()*b.Dy())}
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			alpha := float64(c.A) / 255
+			blend := func(v uint8) float64 {
+				return 255 + (float64(v)-255)*alpha
+			}
+			p.c[y*p.w+x] = [3]float64{blend(c.R), blend(c.G), blend(c.B)}
+		}
+	}
+	return p
+}
+
+// at returns the color of the pixel at x, y.
+func (p *pixels) at(x, y int) [3]float64 {
+	return p.c[y*p.w+x]
+}
+
+// neighbourhood returns the bounds of the 3x3 area around the pixel at x, y,
+// clipped to the image. Pixels on the edges of the image count as having one
+// extra neighbour of the same color, which is returned as same.
+func (p *pixels) neighbourhood(x, y int) (x0, y0, x1, y1, same int) {
+	x0, y0, x1, y1 = x-1, y-1, x+1, y+1
+	if x0 < 0 {
+		x0, same = 0, 1
+	}
+	if y0 < 0 {
+		y0, same = 0, 1
+	}
+	if x1 > p.w-1 {
+		x1, same = p.w-1, 1
+	}
+	if y1 > p.h-1 {
+		y1, same = p.h-1, 1
+	}
+	return x0, y0, x1, y1, same
+}
+
+// yiqY returns the brightness of the color.
+func yiqY(c [3]float64) float64 {
+	return c[0]*0.29889531 + c[1]*0.58662247 + c[2]*0.11448223
+}
+
+// colorDelta returns the squared YIQ distance between the two colors, or only
+// the signed difference of their brightness when yOnly is set.
+func colorDelta(c1, c2 [3]float64, yOnly bool) float64 {
+	if c1 == c2 {
+		return 0
+	}
+	y := yiqY(c1) - yiqY(c2)
+	if yOnly {
+		return y
+	}
+	i := (c1[0]*0.59597799 - c1[1]*0.27417610 - c1[2]*0.32180189) -
+		(c2[0]*0.59597799 - c2[1]*0.27417610 - c2[2]*0.32180189)
+	q := (c1[0]*0.21147017 - c1[1]*0.52261711 + c1[2]*0.31114694) -
+		(c2[0]*0.21147017 - c2[1]*0.52261711 + c2[2]*0.31114694)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+// antialiased returns whether the pixel at x, y of p1 is likely to be part of
+// an anti-aliased edge, by looking at its neighbours in both images.
+func antialiased(p1, p2 *pixels, x, y int) bool {
+	x0, y0, x1, y1, zeroes := p1.neighbourhood(x, y)
+
+	var minDelta, maxDelta float64
+	var minX, minY, maxX, maxY int
+	c := p1.at(x, y)
+	for ny := y0; ny <= y1; ny++ {
+		for nx := x0; nx <= x1; nx++ {
+			if nx == x && ny == y {
+				continue
+			}
+			delta := colorDelta(c, p1.at(nx, ny), true)
+			switch {
+			case delta == 0:
+				zeroes++
+				if zeroes > 2 {
+					return false
+				}
+			case delta < minDelta:
+				minDelta, minX, minY = delta, nx, ny
+			case delta > maxDelta:
+				maxDelta, maxX, maxY = delta, nx, ny
+			}
+		}
+	}
+	// An anti-aliased pixel has both a darker and a brighter neighbour.
+	if minDelta == 0 || maxDelta == 0 {
+		return false
+	}
+	return (hasManySiblings(p1, minX, minY) && hasManySiblings(p2, minX, minY)) ||
+		(hasManySiblings(p1, maxX, maxY) && hasManySiblings(p2, maxX, maxY))
+}
+
+// hasManySiblings returns whether the pixel at x, y has more than two
+// neighbours of the same color.
+func hasManySiblings(p *pixels, x, y int) bool {
+	x0, y0, x1, y1, zeroes := p.neighbourhood(x, y)
+
+	c := p.at(x, y)
+	for ny := y0; ny <= y1; ny++ {
+		for nx := x0; nx <= x1; nx++ {
+			if nx == x && ny == y {
+				continue
+			}
+			if p.at(nx, ny) == c {
+				zeroes++
+			}
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package chromedp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDiffImages(t *testing.T) {
+	t.Parallel()
+
+	// fill returns a w x h image of color c, with the pixels for which
+	// fn returns true set to color d.
+	fill := func(w, h int, c, d color.Color, fn func(x, y int) bool) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if fn != nil && fn(x, y) {
+					img.Set(x, y, d)
+				} else {
+					img.Set(x, y, c)
+				}
+			}
+		}
+		return img
+	}
+	white, black := color.White, color.Black
+	square := func(x, y int) bool { return x >= 2 && x < 6 && y >= 2 && y < 6 }
+	corner := func(x, y int) bool { return x == 0 && y == 0 }
+
+	tests := []struct {
+		name string
+		a, b image.Image
+		opts []DiffOption
+		want int
+	}{
+		{"Identical", fill(10, 10, white, nil, nil), fill(10, 10, white, nil, nil), nil, 0},
+		{"Square", fill(10, 10, white, nil, nil), fill(10, 10, white, black, square), nil, 16},
+		{"Tolerated", fill(10, 10, white, nil, nil), fill(10, 10, color.Gray{250}, nil, nil), nil, 0},
+		{"Exact", fill(10, 10, white, nil, nil), fill(10, 10, color.Gray{250}, nil, nil), []DiffOption{DiffThreshold(0)}, 100},
+		{"Pixel", fill(10, 10, white, nil, nil), fill(10, 10, white, black, corner), []DiffOption{DiffIncludeAntialiasing}, 1},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &diffOptions{threshold: 0.1}
+			for _, opt := range append(test.opts, DiffWithImage) {
+				opt(o)
+			}
+			res, err := diffImages(test.a, test.b, o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.DiffPixels != test.want {
+				t.Errorf("expected %d differing pixels, got: %d", test.want, res.DiffPixels)
+			}
+			if want := 100 * float64(test.want) / 100; res.Percent != want {
+				t.Errorf("expected %f%% differing pixels, got: %f%%", want, res.Percent)
+			}
+			img, err := png.Decode(bytes.NewReader(res.Image))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := img.Bounds().Size(); size.X != 10 || size.Y != 10 {
+				t.Errorf("expected a 10x10 diff image, got: %dx%d", size.X, size.Y)
+			}
+		})
+	}
+
+	if _, err := diffImages(fill(10, 10, white, nil, nil), fill(5, 10, white, nil, nil), &diffOptions{}); err == nil {
+		t.Error("expected an error for images of different sizes")
+	}
+}
+
+func TestScreenshotDiff(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var baseline, current []byte
+	var diff DiffResult
+	if err := Run(ctx,
+		CaptureScreenshot(&baseline),
+		ScreenshotDiff(baseline, &current, &diff),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if diff.DiffPixels != 0 {
+		t.Errorf("expected no differences, got: %d", diff.DiffPixels)
+	}
+
+	if err := Run(ctx,
+		Evaluate(`document.body.style.background = 'blue'`, &[]byte{}),
+		ScreenshotDiff(baseline, &current, &diff),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if diff.DiffPixels == 0 || diff.Percent <= 0 {
+		t.Errorf("expected differences after changing the background, got: %+v", diff)
+	}
+}
+
+func TestDeltaScreenshot(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var prev, res []byte
+	var bounds image.Rectangle
+	if err := Run(ctx,
+		EmulateViewport(100, 100),
+		DeltaScreenshot(&prev, &res, &bounds),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := image.Rect(0, 0, 100, 100); bounds != want || !bytes.Equal(res, prev) {
+		t.Errorf("expected the first screenshot to be entirely changed, got bounds: %v", bounds)
+	}
+
+	if err := Run(ctx, DeltaScreenshot(&prev, &res, &bounds)); err != nil {
+		t.Fatal(err)
+	}
+	if !bounds.Empty() || res != nil {
+		t.Errorf("expected no changes, got bounds: %v", bounds)
+	}
+
+	if err := Run(ctx,
+		Evaluate(`var box = document.createElement('div');
+		box.style = 'position: absolute; left: 10px; top: 20px; width: 30px; height: 40px; background: red';
+		document.body.appendChild(box);`, &[]byte{}),
+		DeltaScreenshot(&prev, &res, &bounds),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := image.Rect(10, 20, 40, 60); bounds != want {
+		t.Errorf("expected changed bounds %v, got: %v", want, bounds)
+	}
+	img, err := png.Decode(bytes.NewReader(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 30 || size.Y != 40 {
+		t.Errorf("expected a 30x40 image, got: %dx%d", size.X, size.Y)
+	}
+}
+
+func TestPageHash(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const body = `document.body.innerHTML = %q;`
+	var first, reordered, changed, ignored, shot, shotChanged string
+	if err := Run(ctx,
+		Evaluate(fmt.Sprintf(body, `<p class="a" id="b" nonce="1">hello   world</p><!-- comment -->`), &[]byte{}),
+		PageHash(&first),
+		Evaluate(fmt.Sprintf(body, `<p id="b" class="a" nonce="2">hello world</p>  `), &[]byte{}),
+		PageHash(&reordered),
+		Evaluate(fmt.Sprintf(body, `<p id="b" class="a" data-token="x">goodbye world</p>`), &[]byte{}),
+		PageHash(&changed),
+		PageHash(&ignored, HashIgnoreAttributes("data-token")),
+		PageHash(&shot, HashScreenshot),
+		Evaluate(`document.body.style.background = 'blue'`, &[]byte{}),
+		PageHash(&shotChanged, HashScreenshot),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 64 {
+		t.Errorf("expected a hex-encoded SHA-256 sum, got: %q", first)
+	}
+	if first != reordered {
+		t.Errorf("expected the normalized DOM hashes to match, got: %s and %s", first, reordered)
+	}
+	if changed == first || changed == ignored {
+		t.Errorf("expected the hash to change with the text and attributes, got: %s", changed)
+	}
+	if shot == shotChanged {
+		t.Errorf("expected the screenshot hash to change with the background, got: %s", shot)
+	}
+}
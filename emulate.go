@@ -1,8 +1,21 @@
 package chromedp
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/deviceorientation"
 	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp/device"
+	"github.com/mailru/easyjson/jwriter"
 )
 
 // EmulateAction are actions that change the emulation settings for the
@@ -80,6 +93,779 @@ func ResetViewport() EmulateAction {
 	return EmulateViewport(0, 0, EmulatePortrait)
 }
 
+// SetScreenOrientation is an action that emulates rotating the device to the
+// specified screen orientation and angle, keeping the current viewport size
+// (swapping its width and height when switching between portrait and
+// landscape) and device pixel ratio. The action waits until screen.orientation
+// reflects the change, by which point the browser has fired the
+// orientationchange event if the angle changed, and returns an error if it
+// doesn't within a second.
+//
+// Wraps a call to emulation.SetDeviceMetricsOverride. The device is emulated
+// as a mobile one, since window.orientation and the orientationchange event
+// are only available on mobile browsers.
+func SetScreenOrientation(orientation emulation.OrientationType, angle int64) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		var metrics []float64
+		if err := Evaluate(viewportMetricsJS, &metrics).Do(ctx); err != nil {
+			return err
+		}
+		width, height := int64(metrics[0]), int64(metrics[1])
+		landscape := orientation == emulation.OrientationTypeLandscapePrimary ||
+			orientation == emulation.OrientationTypeLandscapeSecondary
+		if landscape != (width > height) && width != height {
+			width, height = height, width
+		}
+
+		const key = "__chromedpOrientationChange"
+		const timeout = time.Second
+		if err := Evaluate(fmt.Sprintf(orientationChangeJS, key, orientation, angle, timeout.Milliseconds()), &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+		if err := emulation.SetDeviceMetricsOverride(width, height, metrics[2], true).
+			WithScreenOrientation(&emulation.ScreenOrientation{
+				Type:  orientation,
+				Angle: angle,
+			}).Do(ctx); err != nil {
+			return err
+		}
+		var changed bool
+		if err := Evaluate("window."+key, &changed, evalAwaitPromise).Do(ctx); err != nil {
+			return err
+		}
+		if !changed {
+			return fmt.Errorf("screen orientation did not change to %s at %d degrees within %v", orientation, angle, timeout)
+		}
+		return nil
+	})
+}
+
+// zoomState is the state of the last SetZoom action of a target.
+type zoomState struct {
+	// factor is the zoom factor that was applied.
+	factor float64
+
+	// metrics is the resulting viewport width, height and device pixel
+	// ratio, used to detect whether the zoom was overridden meanwhile.
+	metrics [3]float64
+}
+
+// SetZoom is an action that emulates zooming the page to the specified factor
+// (eg, 2 for 200%), like the browser zoom does. Unlike the page scale factor,
+// zooming shrinks the CSS viewport while keeping its physical size, so layout,
+// media queries and the device pixel ratio respond as they would to a real
+// zoom. Use a factor of 1 to reset the zoom.
+//
+// Useful for testing that a page stays usable at 200%, as required by WCAG.
+//
+// Wraps a call to emulation.SetDeviceMetricsOverride, which overrides any
+// previous viewport emulation, such as the one of EmulateViewport; those reset
+// the zoom too.
+func SetZoom(factor float64) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		if factor <= 0 {
+			return fmt.Errorf("invalid zoom factor %v", factor)
+		}
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		var metrics [3]float64
+		if err := Evaluate(viewportMetricsJS, &metrics).Do(ctx); err != nil {
+			return err
+		}
+		// Undo the current zoom, if any, to get the unzoomed viewport.
+		if prev := t.zoom; prev.factor != 0 && prev.metrics == metrics {
+			metrics = [3]float64{metrics[0] * prev.factor, metrics[1] * prev.factor, metrics[2] / prev.factor}
+		}
+
+		width := int64(math.Round(metrics[0] / factor))
+		height := int64(math.Round(metrics[1] / factor))
+		ratio := metrics[2] * factor
+		if err := emulation.SetDeviceMetricsOverride(width, height, ratio, false).Do(ctx); err != nil {
+			return err
+		}
+		t.zoom = zoomState{factor: factor}
+		if err := Evaluate(viewportMetricsJS, &metrics).Do(ctx); err != nil {
+			return err
+		}
+		t.zoom.metrics = metrics
+		return nil
+	})
+}
+
+// SetViewportSize is an action that resizes the browser window containing the
+// current target, so that its content viewport (ie, window.innerWidth and
+// window.innerHeight) is exactly width by height pixels.
+//
+// Unlike EmulateViewport, the window itself is resized, taking into account
+// the size of the browser UI surrounding the viewport when not running in
+// headless mode.
+func SetViewportSize(width, height int) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		windowID, _, err := browser.GetWindowForTarget().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		// measure the difference between the outer and inner sizes
+		var size [4]int
+		if err := Evaluate(windowSizeJS, &size).Do(ctx); err != nil {
+			return err
+		}
+		bounds := &browser.Bounds{
+			Width:       int64(width + size[2] - size[0]),
+			Height:      int64(height + size[3] - size[1]),
+			WindowState: browser.WindowStateNormal,
+		}
+		if err := browser.SetWindowBounds(windowID, bounds).Do(ctx); err != nil {
+			return err
+		}
+
+		// the window is resized asynchronously, so wait for the new size
+		tctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		err = waitFor(tctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			if err := Evaluate(windowSizeJS, &size).Do(ctx); err != nil {
+				return false, err
+			}
+			return size[0] == width && size[1] == height, nil
+		})
+		switch {
+		case err == context.DeadlineExceeded && ctx.Err() == nil:
+			return fmt.Errorf("could not set viewport size to %dx%d, got %dx%d", width, height, size[0], size[1])
+		case err != nil:
+			return err
+		}
+		return nil
+	})
+}
+
+// EmulateDeviceOrientation is an action to override the device orientation
+// reported to the page, such as via deviceorientation events. The angles are
+// in degrees.
+//
+// Wraps calls to deviceorientation.SetDeviceOrientationOverride.
+func EmulateDeviceOrientation(alpha, beta, gamma float64) EmulateAction {
+	return deviceorientation.SetDeviceOrientationOverride(alpha, beta, gamma)
+}
+
+// EmulateDeviceOrientationReset is an action to clear the device orientation
+// override set by EmulateDeviceOrientation.
+func EmulateDeviceOrientationReset() EmulateAction {
+	return deviceorientation.ClearDeviceOrientationOverride()
+}
+
+// DeviceMotion holds the readings of a devicemotion event.
+type DeviceMotion struct {
+	// Acceleration is the acceleration of the device along the x, y and z
+	// axes, in m/s².
+	Acceleration [3]float64
+
+	// AccelerationIncludingGravity is the acceleration of the device along
+	// the x, y and z axes including the effect of gravity, in m/s².
+	AccelerationIncludingGravity [3]float64
+
+	// RotationRate is the rate of rotation of the device around the alpha,
+	// beta and gamma axes, in deg/s.
+	RotationRate [3]float64
+}
+
+// SetSensorOverride is an action that overrides the readings of the device's
+// motion sensors with the specified accelerometer and gyroscope readings,
+// which are then reported by the devicemotion events of the current page, as
+// well as by the Generic Sensor API (eg, Accelerometer). The readings last
+// until they are overridden again, or until ClearSensorOverride.
+//
+// Requires Chrome 125 or later. Sends raw Emulation.setSensorOverrideEnabled
+// and Emulation.setSensorOverrideReadings commands, as they're not available
+// in the version of the emulation package in use; switch to the emulation
+// package once cdproto is updated.
+func SetSensorOverride(motion DeviceMotion) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		rotation := motion.RotationRate
+		for i := range rotation {
+			// the gyroscope reports radians per second
+			rotation[i] *= math.Pi / 180
+		}
+		readings := []sensorReadingsParams{
+			{"linear-acceleration", motion.Acceleration},
+			{"accelerometer", motion.AccelerationIncludingGravity},
+			{"gyroscope", rotation},
+		}
+		for _, r := range readings {
+			if err := cdp.Execute(ctx, "Emulation.setSensorOverrideEnabled", sensorOverrideParams{true, r.Type}, nil); err != nil {
+				return err
+			}
+			if err := cdp.Execute(ctx, "Emulation.setSensorOverrideReadings", r, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ClearSensorOverride is an action to clear the motion sensor overrides set by
+// SetSensorOverride. Requires Chrome 125 or later.
+func ClearSensorOverride() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		for _, typ := range []string{"linear-acceleration", "accelerometer", "gyroscope"} {
+			if err := cdp.Execute(ctx, "Emulation.setSensorOverrideEnabled", sensorOverrideParams{false, typ}, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sensorOverrideParams are the parameters of the
+// Emulation.setSensorOverrideEnabled command.
+type sensorOverrideParams struct {
+	Enabled bool
+	Type    string
+}
+
+// MarshalEasyJSON satisfies easyjson.Marshaler.
+func (p sensorOverrideParams) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"enabled":`)
+	w.Bool(p.Enabled)
+	w.RawString(`,"type":`)
+	w.String(p.Type)
+	w.RawByte('}')
+}
+
+// sensorReadingsParams are the parameters of the
+// Emulation.setSensorOverrideReadings command, for a sensor reporting x, y
+// and z values.
+type sensorReadingsParams struct {
+	Type string
+	XYZ  [3]float64
+}
+
+// MarshalEasyJSON satisfies easyjson.Marshaler.
+func (p sensorReadingsParams) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"type":`)
+	w.String(p.Type)
+	w.RawString(`,"reading":{"xyz":{"x":`)
+	w.Float64(p.XYZ[0])
+	w.RawString(`,"y":`)
+	w.Float64(p.XYZ[1])
+	w.RawString(`,"z":`)
+	w.Float64(p.XYZ[2])
+	w.RawString(`}}}`)
+}
+
+// SetClientHints is an action that overrides the effective connection type
+// reported by navigator.connection.effectiveType (one of "slow-2g", "2g",
+// "3g" or "4g"), and the amount of memory reported by navigator.deviceMemory,
+// in the current document and in any document loaded afterwards. An empty ect,
+// or a deviceMemory of 0, leaves the respective value unchanged, including the
+// value set by a previous call.
+//
+// Useful for testing pages that adapt the content they load to the network
+// and device capabilities. Note that only the values seen by page scripts are
+// overridden; neither the network nor the Client Hints request headers are
+// affected.
+func SetClientHints(ect string, deviceMemory float64) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		switch ect {
+		case "", "slow-2g", "2g", "3g", "4g":
+		default:
+			return fmt.Errorf("invalid effective connection type %q", ect)
+		}
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		// keep the values overridden by previous calls
+		state := t.clientHints
+		if ect != "" {
+			state.ect = ect
+		}
+		if deviceMemory > 0 {
+			state.deviceMemory = deviceMemory
+		}
+		var ectArg, memoryArg interface{}
+		if state.ect != "" {
+			ectArg = state.ect
+		}
+		if state.deviceMemory > 0 {
+			memoryArg = state.deviceMemory
+		}
+		ectJSON, err := json.Marshal(ectArg)
+		if err != nil {
+			return err
+		}
+		memoryJSON, err := json.Marshal(memoryArg)
+		if err != nil {
+			return err
+		}
+
+		script := fmt.Sprintf(clientHintsJS, ectJSON, memoryJSON)
+		if err := replaceInitScript(ctx, &t.clientHints.scriptID, script); err != nil {
+			return err
+		}
+		t.clientHints.ect, t.clientHints.deviceMemory = state.ect, state.deviceMemory
+		return Evaluate(script, &[]byte{}).Do(ctx)
+	})
+}
+
+// clientHintsState is the state of the last SetClientHints action of a
+// target.
+type clientHintsState struct {
+	// scriptID is the script applying the overrides in new documents.
+	scriptID page.ScriptIdentifier
+
+	// ect and deviceMemory are the overridden values, if any.
+	ect          string
+	deviceMemory float64
+}
+
+// SetHardwareConcurrency is an action that overrides the number of logical
+// processors reported by navigator.hardwareConcurrency, in the current
+// document and in any document loaded afterwards, before the page's scripts
+// run. Useful for testing pages that size their pools of workers after it.
+//
+// Note: only the value seen by the documents' scripts is overridden; the
+// workers they start still see the real value.
+func SetHardwareConcurrency(n int) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if n < 1 {
+			return fmt.Errorf("invalid hardware concurrency %d", n)
+		}
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		script := fmt.Sprintf(hardwareConcurrencyJS, n)
+		if err := replaceInitScript(ctx, &t.hardwareConcurrencyScript, script); err != nil {
+			return err
+		}
+		return Evaluate(script, &[]byte{}).Do(ctx)
+	})
+}
+
+// SetSaveData is an action that emulates the user's data saver preference,
+// sending the "Save-Data: on" request header when enabled, and overriding
+// navigator.connection.saveData accordingly in the current document and in any
+// document loaded afterwards.
+//
+// Note: the Network domain is enabled. The header is sent via
+// network.SetExtraHTTPHeaders, so extra headers set directly that way replace
+// it, and are replaced by the next call.
+func SetSaveData(enabled bool) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+		value := ""
+		if enabled {
+			value = "on"
+		}
+		if err := t.setExtraHeader(ctx, "Save-Data", value); err != nil {
+			return err
+		}
+
+		// when disabled, new documents report the browser's own value
+		script, next := fmt.Sprintf(saveDataJS, enabled), ""
+		if enabled {
+			next = script
+		}
+		if err := replaceInitScript(ctx, &t.saveDataScript, next); err != nil {
+			return err
+		}
+		return Evaluate(script, &[]byte{}).Do(ctx)
+	})
+}
+
+// setExtraHeader sets the extra HTTP header name of the target to value, or
+// removes it when value is empty, keeping the extra headers set by the other
+// actions. It must be called with emulationMu held.
+func (t *Target) setExtraHeader(ctx context.Context, name, value string) error {
+	if t.extraHeaders == nil {
+		t.extraHeaders = make(map[string]interface{})
+	}
+	delete(t.extraHeaders, name)
+	if value != "" {
+		t.extraHeaders[name] = value
+	}
+	buf, err := json.Marshal(t.extraHeaders)
+	if err != nil {
+		return err
+	}
+	return network.SetExtraHTTPHeaders(network.Headers(buf)).Do(ctx)
+}
+
+// replaceInitScript removes the script evaluated on new documents identified
+// by id, if any, and adds script instead, unless it's empty, updating id.
+func replaceInitScript(ctx context.Context, id *page.ScriptIdentifier, script string) error {
+	if *id != "" {
+		if err := page.RemoveScriptToEvaluateOnNewDocument(*id).Do(ctx); err != nil {
+			return err
+		}
+		*id = ""
+	}
+	if script == "" {
+		return nil
+	}
+	newID, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
+
+// SetCookiesEnabled is an action that emulates the user enabling or disabling
+// cookies, by overriding navigator.cookieEnabled in the current document and in
+// any document loaded afterwards. While cookies are disabled, document.cookie
+// reads as an empty string and writes to it are ignored.
+//
+// Useful to test that a page degrades gracefully when cookies are disabled.
+// Cookies set via the Set-Cookie headers of responses are not affected, unless
+// the CookiesBlockHeaders option is used.
+//
+// Each call replaces the settings of the previous one, so enabling cookies
+// again undoes everything a call disabling them did.
+func SetCookiesEnabled(enabled bool, opts ...CookiesOption) Action {
+	o := new(cookiesOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		// undo the previous call, so that calls don't pile up
+		if t.cookies.cancel != nil {
+			t.cookies.cancel()
+			t.cookies.cancel = nil
+		}
+		if t.cookies.scriptID != "" {
+			if err := page.RemoveScriptToEvaluateOnNewDocument(t.cookies.scriptID).Do(ctx); err != nil {
+				return err
+			}
+			t.cookies.scriptID = ""
+		}
+
+		script := fmt.Sprintf(cookiesEnabledJS, enabled)
+		if !enabled {
+			if o.blockHeaders {
+				// the handler outlives ctx, until cookies are enabled
+				// again or the target is gone
+				hctx, cancel := context.WithCancel(cdp.WithExecutor(t.ctx, t))
+				if err := intercept(hctx, &requestHandler{
+					stage: fetch.RequestStageResponse,
+					match: func(e *fetch.EventRequestPaused) bool {
+						return e.ResponseErrorReason == ""
+					},
+					modify: func(ctx context.Context, p *pausedRequest) error {
+						p.delHeader("Set-Cookie")
+						return nil
+					},
+				}); err != nil {
+					cancel()
+					return err
+				}
+				t.cookies.cancel = cancel
+			}
+
+			id, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx)
+			if err != nil {
+				return err
+			}
+			t.cookies.scriptID = id
+		}
+		return Evaluate(script, &[]byte{}).Do(ctx)
+	})
+}
+
+// cookiesState is the state of the last SetCookiesEnabled action of a target.
+type cookiesState struct {
+	// scriptID is the script disabling cookies in new documents.
+	scriptID page.ScriptIdentifier
+
+	// cancel unregisters the handler removing the Set-Cookie headers.
+	cancel context.CancelFunc
+}
+
+// cookiesOptions holds the options of SetCookiesEnabled.
+type cookiesOptions struct {
+	blockHeaders bool
+}
+
+// CookiesOption is a SetCookiesEnabled action option.
+type CookiesOption = func(*cookiesOptions)
+
+// CookiesBlockHeaders is a SetCookiesEnabled action option to also remove the
+// Set-Cookie headers of the responses received by the current target while
+// cookies are disabled, via the Fetch domain.
+func CookiesBlockHeaders(o *cookiesOptions) {
+	o.blockHeaders = true
+}
+
+// SetPermissionState is an action that overrides the state of the permission
+// name, such as "notifications" or "geolocation", as reported by
+// navigator.permissions.query in the current document and in any document
+// loaded afterwards. state must be one of "granted", "denied" or "prompt", or
+// the action returns an error. For the "notifications" permission,
+// Notification.permission is overridden as well. Each permission is overridden
+// separately, and each call replaces the state set by the previous one for the
+// same permission.
+//
+// Useful to test how a page gates its features on permissions, without any
+// permission prompts. Note that only the reported state is affected: the
+// browser still enforces the real permissions.
+func SetPermissionState(name, state string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		switch state {
+		case "granted", "denied", "prompt":
+		default:
+			return fmt.Errorf("invalid permission state %q", state)
+		}
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		nameJSON, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		stateJSON, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		script := fmt.Sprintf(permissionStateJS, nameJSON, stateJSON)
+		if t.permissionScripts == nil {
+			t.permissionScripts = make(map[string]page.ScriptIdentifier)
+		}
+		id := t.permissionScripts[name]
+		if err := replaceInitScript(ctx, &id, script); err != nil {
+			return err
+		}
+		t.permissionScripts[name] = id
+		return Evaluate(script, &[]byte{}).Do(ctx)
+	})
+}
+
+// SetOnline is an action that emulates the browser going online or offline,
+// by emulating the network conditions via network.EmulateNetworkConditions,
+// and overriding navigator.onLine in the current document and in any document
+// loaded afterwards. The online or offline event is dispatched in the current
+// document when the value changes, so that the page's listeners react.
+//
+// The events the browser fires when the network conditions change are not
+// reliable, so they are stopped before reaching the page's listeners; as such,
+// the events seen by the page are the ones dispatched by this action.
+//
+// Note: the Network domain is enabled. Going online clears any other network
+// conditions emulated previously.
+func SetOnline(online bool) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if err := network.EmulateNetworkConditions(!online, 0, -1, -1).Do(ctx); err != nil {
+			return err
+		}
+		if err := replaceInitScript(ctx, &t.onlineScript, fmt.Sprintf(onlineJS, online, false)); err != nil {
+			return err
+		}
+		return Evaluate(fmt.Sprintf(onlineJS, online, true), &[]byte{}).Do(ctx)
+	})
+}
+
+// OverrideMatchMedia is an action that overrides whether the media query
+// matches, as reported by window.matchMedia in the current document and in any
+// document loaded afterwards. Each query is overridden separately, and each call
+// replaces the override set by the previous one for the same query.
+//
+// Useful for media features which can't be emulated via
+// emulation.SetEmulatedMedia. Note that only scripts are affected: the page's
+// CSS @media rules are still evaluated against the real conditions.
+func OverrideMatchMedia(query string, matches bool) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		queryJSON, err := json.Marshal(query)
+		if err != nil {
+			return err
+		}
+		script := fmt.Sprintf(matchMediaJS, queryJSON, matches)
+		if t.matchMediaScripts == nil {
+			t.matchMediaScripts = make(map[string]page.ScriptIdentifier)
+		}
+		id := t.matchMediaScripts[query]
+		if err := replaceInitScript(ctx, &id, script); err != nil {
+			return err
+		}
+		t.matchMediaScripts[query] = id
+		return Evaluate(script, &[]byte{}).Do(ctx)
+	})
+}
+
+// DisableSmoothScroll is an action that disables smooth scrolling, by
+// overriding the scroll-behavior CSS property of all the elements of the
+// current document and of any document loaded afterwards, so that the scrolls
+// done by the page, or by actions such as ScrollIntoView, are instant and
+// screenshots aren't captured in the middle of a scroll animation.
+//
+// Running the action again on the same target has no further effect.
+//
+// Note: scrolls which explicitly request a smooth behavior from script, such
+// as via window.scrollTo({behavior: 'smooth'}), are not affected.
+func DisableSmoothScroll() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		if err := replaceInitScript(ctx, &t.smoothScrollScript, smoothScrollJS); err != nil {
+			return err
+		}
+		return Evaluate(smoothScrollJS, &[]byte{}).Do(ctx)
+	})
+}
+
+// SuppressInfobars is an action that keeps Chrome from showing its
+// "Translate this page" prompt on the current target, for the current page
+// and the ones navigated to afterwards, by marking the documents as not to be
+// translated. Such prompts shift the page's layout in headful mode, making
+// screenshots differ from one run to another. Running the action again on the
+// same target has no further effect.
+//
+// Note: the state of the browser itself, such as the "Save password" prompt,
+// can't be changed at runtime via the DevTools Protocol. Use the
+// DisableInfobars allocator option to suppress those prompts too.
+func SuppressInfobars() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		if err := replaceInitScript(ctx, &t.noTranslateScript, noTranslateJS); err != nil {
+			return err
+		}
+		return Evaluate(noTranslateJS, &[]byte{}).Do(ctx)
+	})
+}
+
+// VisionDeficiency is a vision deficiency to emulate.
+type VisionDeficiency string
+
+// Vision deficiency values.
+const (
+	VisionDeficiencyNone          VisionDeficiency = "none"
+	VisionDeficiencyAchromatopsia VisionDeficiency = "achromatopsia"
+	VisionDeficiencyBlurredVision VisionDeficiency = "blurredVision"
+	VisionDeficiencyDeuteranopia  VisionDeficiency = "deuteranopia"
+	VisionDeficiencyProtanopia    VisionDeficiency = "protanopia"
+	VisionDeficiencyTritanopia    VisionDeficiency = "tritanopia"
+)
+
+// EmulateVisionDeficiency is an action that emulates a vision deficiency,
+// such as achromatopsia (ie, no color vision), for the rendering of the current
+// page, affecting the page and the screenshots captured afterwards.
+// VisionDeficiencyNone resets the emulation.
+//
+// Useful for checking that a page remains usable for color-blind users, by
+// capturing a screenshot under each deficiency.
+//
+// Sends a raw Emulation.setEmulatedVisionDeficiency command, as it's not
+// available in the version of the emulation package in use, so it requires a
+// browser supporting it.
+func EmulateVisionDeficiency(typ VisionDeficiency) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		return cdp.Execute(ctx, "Emulation.setEmulatedVisionDeficiency", visionDeficiencyParams{typ}, nil)
+	})
+}
+
+// visionDeficiencyParams are the parameters of the
+// Emulation.setEmulatedVisionDeficiency command.
+type visionDeficiencyParams struct {
+	Type VisionDeficiency
+}
+
+// MarshalEasyJSON satisfies easyjson.Marshaler.
+func (p visionDeficiencyParams) MarshalEasyJSON(w *jwriter.Writer) {
+	w.RawString(`{"type":`)
+	w.String(string(p.Type))
+	w.RawByte('}')
+}
+
+// SetVirtualTimePolicy is an action that sets the virtual time policy of the
+// current page. When budget (in milliseconds of virtual time) is positive, the
+// action waits until the budget has expired, at which point the virtual time
+// is paused again.
+//
+// Useful for capturing the frames of an animation at precise moments: pause
+// the virtual time, and then advance it by the desired budget before taking
+// each screenshot.
+//
+// Wraps a call to emulation.SetVirtualTimePolicy, handling the
+// emulation.EventVirtualTimeBudgetExpired event.
+func SetVirtualTimePolicy(policy emulation.VirtualTimePolicy, budget float64) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		p := emulation.SetVirtualTimePolicy(policy)
+		if budget <= 0 {
+			_, err := p.Do(ctx)
+			return err
+		}
+
+		expect, release := expectEvent(ctx, func(ev interface{}) bool {
+			_, ok := ev.(*emulation.EventVirtualTimeBudgetExpired)
+			return ok
+		})
+		defer release()
+		if _, err := p.WithBudget(budget).Do(ctx); err != nil {
+			return err
+		}
+		return expect()
+	})
+}
+
 // Device is the shared interface for known device types.
 //
 // See: github.com/chromedp/chromedp/device for a set of off-the-shelf devices
@@ -121,3 +907,166 @@ func Emulate(device Device) EmulateAction {
 func EmulateReset() EmulateAction {
 	return Emulate(device.Reset)
 }
+
+// EmulationProfile bundles the emulation settings of a persona, such as a
+// mobile user in Tokyo on a slow network, to be applied at once via
+// ApplyProfile. The zero value of each setting leaves it unchanged.
+type EmulationProfile struct {
+	// Device is the device to emulate, as with Emulate.
+	Device Device
+
+	// UserAgent overrides the user agent, including the one of Device.
+	UserAgent string
+
+	// Locale is the locale, such as "ja-JP", used for the Accept-Language
+	// header and navigator.language.
+	Locale string
+
+	// Timezone is the IANA time zone ID, such as "Asia/Tokyo".
+	Timezone string
+
+	// Geolocation is the position reported by the Geolocation API.
+	Geolocation *Geolocation
+
+	// Network is the network conditions to emulate.
+	Network *NetworkConditions
+
+	// Media is the CSS media type to emulate, such as "print", and
+	// MediaFeatures are the CSS media features to emulate, such as
+	// prefers-color-scheme.
+	Media         string
+	MediaFeatures []*emulation.MediaFeature
+}
+
+// Geolocation is a position reported by the Geolocation API.
+type Geolocation struct {
+	Latitude, Longitude float64
+
+	// Accuracy is the accuracy of the position, in meters.
+	Accuracy float64
+}
+
+// NetworkConditions are network conditions to emulate.
+type NetworkConditions struct {
+	Offline bool
+
+	// Latency is the minimum latency of the requests, in milliseconds.
+	Latency float64
+
+	// DownloadThroughput and UploadThroughput are the maximum throughputs,
+	// in bytes per second, or -1 to disable throttling.
+	DownloadThroughput float64
+	UploadThroughput   float64
+}
+
+// ApplyProfile is an action that applies the emulation settings of the
+// profile to the current target, keeping track of them so that they can be
+// reverted via ClearEmulation. The settings of a profile applied previously
+// are cleared first, so that profiles don't pile up.
+//
+// Note: the geolocation is only reported to pages which were granted the
+// permission to use it, and the Network domain is enabled when emulating
+// network conditions.
+func ApplyProfile(p EmulationProfile) EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+		if err := t.clearProfile(ctx); err != nil {
+			return err
+		}
+
+		var actions []Action
+		userAgent := p.UserAgent
+		if p.Device != nil {
+			actions = append(actions, Emulate(p.Device))
+			if userAgent == "" {
+				userAgent = p.Device.Device().UserAgent
+			}
+		}
+		if userAgent == "" && p.Locale != "" {
+			var info VersionInfo
+			if err := Version(&info).Do(ctx); err != nil {
+				return err
+			}
+			userAgent = info.UserAgent
+		}
+		if p.UserAgent != "" || p.Locale != "" {
+			actions = append(actions, emulation.SetUserAgentOverride(userAgent).WithAcceptLanguage(p.Locale))
+		}
+		if p.Timezone != "" {
+			actions = append(actions, emulation.SetTimezoneOverride(p.Timezone))
+		}
+		if g := p.Geolocation; g != nil {
+			actions = append(actions, emulation.SetGeolocationOverride().
+				WithLatitude(g.Latitude).
+				WithLongitude(g.Longitude).
+				WithAccuracy(g.Accuracy))
+		}
+		if n := p.Network; n != nil {
+			actions = append(actions, network.Enable(),
+				network.EmulateNetworkConditions(n.Offline, n.Latency, n.DownloadThroughput, n.UploadThroughput))
+		}
+		if p.Media != "" || len(p.MediaFeatures) > 0 {
+			actions = append(actions, emulation.SetEmulatedMedia().WithMedia(p.Media).WithFeatures(p.MediaFeatures))
+		}
+
+		// keep track of the profile before applying it, so that the
+		// settings applied before a failure can be cleared too
+		t.profile = &p
+		return Tasks(actions).Do(ctx)
+	})
+}
+
+// ClearEmulation is an action that reverts the emulation settings applied to
+// the current target by ApplyProfile, resetting them to the browser's defaults.
+// It does nothing if no profile was applied.
+func ClearEmulation() EmulateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+		return t.clearProfile(ctx)
+	})
+}
+
+// clearProfile reverts the settings of the profile applied by ApplyProfile,
+// if any. The caller must hold emulationMu.
+func (t *Target) clearProfile(ctx context.Context) error {
+	p := t.profile
+	if p == nil {
+		return nil
+	}
+
+	var actions []Action
+	if p.Device != nil {
+		actions = append(actions, EmulateReset())
+	}
+	if p.UserAgent != "" || p.Locale != "" {
+		// an empty user agent clears the override
+		actions = append(actions, emulation.SetUserAgentOverride(""))
+	}
+	if p.Timezone != "" {
+		actions = append(actions, emulation.SetTimezoneOverride(""))
+	}
+	if p.Geolocation != nil {
+		actions = append(actions, emulation.ClearGeolocationOverride())
+	}
+	if p.Network != nil {
+		actions = append(actions, network.EmulateNetworkConditions(false, 0, -1, -1))
+	}
+	if p.Media != "" || len(p.MediaFeatures) > 0 {
+		actions = append(actions, emulation.SetEmulatedMedia())
+	}
+	if err := Tasks(actions).Do(ctx); err != nil {
+		return err
+	}
+	t.profile = nil
+	return nil
+}
@@ -2,9 +2,18 @@ package chromedp
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp/device"
 )
 
@@ -31,3 +40,618 @@ func TestEmulate(t *testing.T) {
 		t.Errorf("expected size 400x400, got: %dx%d", size.X, size.Y)
 	}
 }
+
+func TestSetViewportSize(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var size []int
+	if err := Run(ctx,
+		SetViewportSize(500, 400),
+		Evaluate(`[window.innerWidth, window.innerHeight]`, &size),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(size) != 2 || size[0] != 500 || size[1] != 400 {
+		t.Errorf("expected viewport size 500x400, got: %v", size)
+	}
+}
+
+func TestEmulateDeviceOrientation(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res []float64
+	if err := Run(ctx,
+		EmulateDeviceOrientation(10, 20, 30),
+		Evaluate(`new Promise(function(resolve) {
+			window.addEventListener('deviceorientation', function(e) {
+				resolve([e.alpha, e.beta, e.gamma]);
+			});
+		})`, &res, evalAwaitPromise),
+		EmulateDeviceOrientationReset(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{10, 20, 30}; !reflect.DeepEqual(res, want) {
+		t.Errorf("expected orientation %v, got: %v", want, res)
+	}
+}
+
+func TestSetSensorOverride(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res []float64
+	if err := Run(ctx,
+		SetSensorOverride(DeviceMotion{
+			Acceleration:                 [3]float64{1, 2, 3},
+			AccelerationIncludingGravity: [3]float64{1, 2, 12.8},
+			RotationRate:                 [3]float64{4, 5, 6},
+		}),
+		Evaluate(`new Promise(function(resolve) {
+			window.addEventListener('devicemotion', function(e) {
+				resolve([e.acceleration.x, e.accelerationIncludingGravity.z, Math.round(e.rotationRate.beta * 1000) / 1000]);
+			}, {once: true});
+		})`, &res, evalAwaitPromise),
+		ClearSensorOverride(),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{1, 12.8, 5}; !reflect.DeepEqual(res, want) {
+		t.Errorf("expected motion readings %v, got: %v", want, res)
+	}
+}
+
+func TestSetClientHints(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, after []interface{}
+	if err := Run(ctx,
+		SetClientHints("2g", 0.5),
+		Evaluate(`[navigator.connection.effectiveType, navigator.deviceMemory]`, &before),
+		Navigate(testdataDir+"/image.html"),
+		Evaluate(`[navigator.connection.effectiveType, navigator.deviceMemory]`, &after),
+	); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"2g", 0.5}
+	if !reflect.DeepEqual(before, want) {
+		t.Errorf("expected %v in the current document, got: %v", want, before)
+	}
+	if !reflect.DeepEqual(after, want) {
+		t.Errorf("expected %v after navigating, got: %v", want, after)
+	}
+
+	// a later call replaces the script, keeping the memory override
+	var updated []interface{}
+	if err := Run(ctx,
+		SetClientHints("4g", 0),
+		Navigate(testdataDir+"/image.html"),
+		Evaluate(`[navigator.connection.effectiveType, navigator.deviceMemory]`, &updated),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"4g", 0.5}; !reflect.DeepEqual(updated, want) {
+		t.Errorf("expected %v after updating, got: %v", want, updated)
+	}
+
+	if err := Run(ctx, SetClientHints("5g", 0)); err == nil {
+		t.Error("expected an error for an invalid connection type")
+	}
+}
+
+func TestSetHardwareConcurrency(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<script>
+	window.early = navigator.hardwareConcurrency;
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var current, early int
+	if err := Run(ctx,
+		SetHardwareConcurrency(3),
+		Evaluate(`navigator.hardwareConcurrency`, &current),
+		Navigate(s.URL),
+		Evaluate(`window.early`, &early),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if current != 3 {
+		t.Errorf("expected 3 cores in the current document, got: %d", current)
+	}
+	if early != 3 {
+		t.Errorf("expected 3 cores before the page's scripts run, got: %d", early)
+	}
+
+	// the script of the previous call is replaced
+	if err := Run(ctx,
+		SetHardwareConcurrency(5),
+		Reload(),
+		Evaluate(`window.early`, &early),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if early != 5 {
+		t.Errorf("expected 5 cores after overriding the value again, got: %d", early)
+	}
+
+	if err := Run(ctx, SetHardwareConcurrency(0)); err == nil {
+		t.Error("expected an error for an invalid number of cores")
+	}
+}
+
+func TestEmulateVisionDeficiency(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<style>body { background: #f00; }</style>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var gray, red []byte
+	if err := Run(ctx,
+		Navigate(s.URL),
+		EmulateVisionDeficiency(VisionDeficiencyAchromatopsia),
+		CaptureScreenshot(&gray),
+		EmulateVisionDeficiency(VisionDeficiencyNone),
+		CaptureScreenshot(&red),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		buf  []byte
+		gray bool
+	}{
+		{gray, true},
+		{red, false},
+	} {
+		img, err := png.Decode(bytes.NewReader(test.buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, g, b, _ := img.At(10, 10).RGBA()
+		if isGray := r == g && g == b; isGray != test.gray {
+			t.Errorf("expected gray to be %v, got color: %d,%d,%d", test.gray, r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestSetScreenOrientation(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res []interface{}
+	if err := Run(ctx,
+		EmulateViewport(400, 800, EmulatePortrait, EmulateMobile),
+		Evaluate(`window.changes = 0;
+		window.addEventListener('orientationchange', function() { window.changes++; });`, &[]byte{}),
+		SetScreenOrientation(emulation.OrientationTypeLandscapePrimary, 90),
+		Evaluate(`[screen.orientation.type, screen.orientation.angle, window.orientation, window.innerWidth > window.innerHeight, window.changes]`, &res),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"landscape-primary", 90.0, 90.0, true, 1.0}; !reflect.DeepEqual(res, want) {
+		t.Errorf("expected %v, got: %v", want, res)
+	}
+}
+
+func TestSetVirtualTimePolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var advanced, paused int
+	if err := Run(ctx,
+		SetVirtualTimePolicy(emulation.VirtualTimePolicyPause, 0),
+		Evaluate(`window.ticks = 0;
+		setInterval(function() { window.ticks++; }, 100);`, &[]byte{}),
+		SetVirtualTimePolicy(emulation.VirtualTimePolicyAdvance, 550),
+		Evaluate(`window.ticks`, &advanced),
+		// the virtual time is paused once the budget expires
+		Sleep(300*time.Millisecond),
+		Evaluate(`window.ticks`, &paused),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if advanced != 5 {
+		t.Errorf("expected 5 ticks after advancing 550ms, got: %d", advanced)
+	}
+	if paused != advanced {
+		t.Errorf("expected no ticks while paused, got: %d", paused-advanced)
+	}
+}
+
+func TestSetSaveData(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<p id="header">%s</p>`, r.Header.Get("Save-Data"))
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var header string
+	var saveData bool
+	if err := Run(ctx,
+		SetSaveData(true),
+		SetSaveData(true),
+		Navigate(s.URL),
+		Text(`#header`, &header, ByID),
+		Evaluate(`navigator.connection.saveData`, &saveData),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if header != "on" || !saveData {
+		t.Errorf("expected the Save-Data header and property to be set, got: %q and %t", header, saveData)
+	}
+
+	if err := Run(ctx,
+		SetSaveData(false),
+		Navigate(s.URL),
+		Text(`#header`, &header, ByID),
+		Evaluate(`navigator.connection.saveData`, &saveData),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if header != "" || saveData {
+		t.Errorf("expected the Save-Data header and property to be unset, got: %q and %t", header, saveData)
+	}
+}
+
+func TestOverrideMatchMedia(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const check = `[
+		matchMedia('(min-width: 10000px)').matches,
+		matchMedia('(MIN-WIDTH:10000px)').matches,
+		matchMedia('(min-width: 1px)').matches
+	]`
+	var before, after []bool
+	if err := Run(ctx,
+		OverrideMatchMedia(`(min-width: 10000px)`, true),
+		Evaluate(check, &before),
+		Navigate(testdataDir+"/image.html"),
+		Evaluate(check, &after),
+	); err != nil {
+		t.Fatal(err)
+	}
+	want := []bool{true, true, true}
+	if !reflect.DeepEqual(before, want) {
+		t.Errorf("expected %v in the current document, got: %v", want, before)
+	}
+	if !reflect.DeepEqual(after, want) {
+		t.Errorf("expected %v after navigating, got: %v", want, after)
+	}
+
+	var overridden, replaced []bool
+	if err := Run(ctx,
+		OverrideMatchMedia(`(min-width: 1px)`, false),
+		Evaluate(check, &overridden),
+		// only the override of the same query is replaced
+		OverrideMatchMedia(`(min-width: 10000px)`, false),
+		Navigate(testdataDir+"/image.html"),
+		Evaluate(check, &replaced),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []bool{true, true, false}; !reflect.DeepEqual(overridden, want) {
+		t.Errorf("expected %v with (min-width: 1px) overridden, got: %v", want, overridden)
+	}
+	if want := []bool{false, false, false}; !reflect.DeepEqual(replaced, want) {
+		t.Errorf("expected %v after replacing the override, got: %v", want, replaced)
+	}
+}
+
+func TestSetZoom(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, zoomed, rezoomed, reset []float64
+	var narrow bool
+	if err := Run(ctx,
+		EmulateViewport(800, 600),
+		Evaluate(viewportMetricsJS, &before),
+		SetZoom(2),
+		Evaluate(viewportMetricsJS, &zoomed),
+		Evaluate(`matchMedia('(max-width: 500px)').matches`, &narrow),
+		SetZoom(4),
+		Evaluate(viewportMetricsJS, &rezoomed),
+		SetZoom(1),
+		Evaluate(viewportMetricsJS, &reset),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{400, 300, before[2] * 2}; !reflect.DeepEqual(zoomed, want) {
+		t.Errorf("expected %v at 200%%, got: %v", want, zoomed)
+	}
+	if !narrow {
+		t.Error("expected media queries to respond to the zoom")
+	}
+	if want := []float64{200, 150, before[2] * 4}; !reflect.DeepEqual(rezoomed, want) {
+		t.Errorf("expected %v at 400%%, got: %v", want, rezoomed)
+	}
+	if !reflect.DeepEqual(reset, before) {
+		t.Errorf("expected %v after resetting the zoom, got: %v", before, reset)
+	}
+}
+
+func TestSetCookiesEnabled(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "disabled-header", Value: "1"})
+		w.Write([]byte(`<script>document.cookie = 'disabled-script=1';</script>`))
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var enabled, reenabled, reloaded bool
+	var cookie, recookie, reloadedCookie string
+	var names []string
+	if err := Run(ctx,
+		SetCookiesEnabled(false, CookiesBlockHeaders),
+		Navigate(s.URL),
+		Evaluate(`navigator.cookieEnabled`, &enabled),
+		Evaluate(`document.cookie`, &cookie),
+		ActionFunc(func(ctx context.Context) error {
+			cookies, err := network.GetAllCookies().Do(ctx)
+			for _, c := range cookies {
+				names = append(names, c.Name)
+			}
+			return err
+		}),
+		SetCookiesEnabled(true),
+		Evaluate(`navigator.cookieEnabled`, &reenabled),
+		Evaluate(`document.cookie = 'enabled-script=1'; document.cookie`, &recookie),
+		// neither the script nor the handler outlive enabling cookies again
+		Navigate(s.URL),
+		Evaluate(`navigator.cookieEnabled`, &reloaded),
+		Evaluate(`document.cookie`, &reloadedCookie),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if enabled || cookie != "" {
+		t.Errorf("expected cookies to be disabled, got: %v %q", enabled, cookie)
+	}
+	for _, name := range names {
+		if name == "disabled-header" || name == "disabled-script" {
+			t.Errorf("expected cookie %q not to be set", name)
+		}
+	}
+	if !reenabled || !strings.Contains(recookie, "enabled-script=1") {
+		t.Errorf("expected cookies to be enabled, got: %v %q", reenabled, recookie)
+	}
+	if !reloaded || !strings.Contains(reloadedCookie, "disabled-header=1") {
+		t.Errorf("expected cookies to be enabled after reloading, got: %v %q", reloaded, reloadedCookie)
+	}
+}
+
+func TestSetPermissionState(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p>permissions</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const query = `Promise.all(['notifications', 'geolocation'].map(function(name) {
+		return navigator.permissions.query({name: name}).then(function(status) {
+			return status.state;
+		});
+	})).then(function(states) {
+		return states.concat(Notification.permission);
+	})`
+	var denied, granted, reloaded []string
+	if err := Run(ctx,
+		SetPermissionState("notifications", "denied"),
+		SetPermissionState("geolocation", "granted"),
+		Navigate(s.URL),
+		Evaluate(query, &denied, evalAwaitPromise),
+		SetPermissionState("notifications", "granted"),
+		Evaluate(query, &granted, evalAwaitPromise),
+		// only the script of the same permission is replaced
+		Reload(),
+		Evaluate(query, &reloaded, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"denied", "granted", "denied"}; !reflect.DeepEqual(denied, want) {
+		t.Errorf("expected states %q, got: %q", want, denied)
+	}
+	if want := []string{"granted", "granted", "granted"}; !reflect.DeepEqual(granted, want) {
+		t.Errorf("expected states %q, got: %q", want, granted)
+	}
+	if want := []string{"granted", "granted", "granted"}; !reflect.DeepEqual(reloaded, want) {
+		t.Errorf("expected states %q after reloading, got: %q", want, reloaded)
+	}
+
+	if err := Run(ctx, SetPermissionState("geolocation", "allowed")); err == nil {
+		t.Error("expected an error for an invalid permission state")
+	}
+}
+
+func TestSetOnline(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var events []string
+	var offline, reloaded, online bool
+	if err := Run(ctx,
+		Evaluate(`window.events = [];
+		window.addEventListener('online', function() { window.events.push('online'); });
+		window.addEventListener('offline', function() { window.events.push('offline'); });`, &[]byte{}),
+		SetOnline(false),
+		// setting the same value again doesn't dispatch an event
+		SetOnline(false),
+		Evaluate(`navigator.onLine`, &offline),
+		SetOnline(true),
+		// the browser's own events are dispatched asynchronously
+		Sleep(100*time.Millisecond),
+		Evaluate(`window.events`, &events),
+		SetOnline(false),
+		Navigate(testdataDir+"/image.html"),
+		Evaluate(`navigator.onLine`, &reloaded),
+		SetOnline(true),
+		// the script of the previous call is replaced
+		Navigate(testdataDir+"/image.html"),
+		Evaluate(`navigator.onLine`, &online),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if offline {
+		t.Error("expected navigator.onLine to be false")
+	}
+	if want := []string{"offline", "online"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected events %v, got: %v", want, events)
+	}
+	if reloaded {
+		t.Error("expected navigator.onLine to be false after navigating")
+	}
+	if !online {
+		t.Error("expected navigator.onLine to be true after navigating")
+	}
+}
+
+func TestDisableSmoothScroll(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<style>html { scroll-behavior: smooth; }</style>
+<div style="height: 5000px"></div>
+<p id="bottom">bottom</p>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var current, reloaded float64
+	var sheets int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		DisableSmoothScroll(),
+		Evaluate(`window.scrollTo(0, 3000); window.scrollY`, &current),
+		// running it again has no further effect
+		DisableSmoothScroll(),
+		Navigate(s.URL+"/?reload"),
+		Evaluate(`window.scrollTo(0, 2000); window.scrollY`, &reloaded),
+		Evaluate(`document.adoptedStyleSheets.length`, &sheets),
+	); err != nil {
+		t.Fatal(err)
+	}
+	// a smooth scroll would still be at the start
+	if current != 3000 {
+		t.Errorf("expected an instant scroll to 3000, got: %f", current)
+	}
+	if reloaded != 2000 {
+		t.Errorf("expected an instant scroll to 2000 after navigating, got: %f", reloaded)
+	}
+	if sheets != 1 {
+		t.Errorf("expected a single adopted stylesheet, got: %d", sheets)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p>profile</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const state = `[navigator.language, Intl.DateTimeFormat().resolvedOptions().timeZone, navigator.userAgent, matchMedia("(prefers-color-scheme: dark)").matches]`
+	var applied, cleared []interface{}
+	if err := Run(ctx,
+		Navigate(s.URL),
+		ApplyProfile(EmulationProfile{
+			UserAgent: "profile-agent",
+			Locale:    "ja-JP",
+			Timezone:  "Asia/Tokyo",
+			MediaFeatures: []*emulation.MediaFeature{
+				{Name: "prefers-color-scheme", Value: "dark"},
+			},
+		}),
+		Navigate(s.URL+"/?applied"),
+		Evaluate(state, &applied),
+		ClearEmulation(),
+		Navigate(s.URL+"/?cleared"),
+		Evaluate(state, &cleared),
+	); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"ja-JP", "Asia/Tokyo", "profile-agent", true}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("expected %v, got: %v", want, applied)
+	}
+	if cleared[1] == "Asia/Tokyo" || cleared[2] == "profile-agent" || cleared[3] == true {
+		t.Errorf("expected the profile to be cleared, got: %v", cleared)
+	}
+}
+
+func TestSuppressInfobars(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p>Bonjour tout le monde</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const state = `document.documentElement.translate && !document.querySelector('meta[content="notranslate"]')`
+	var current, reloaded bool
+	var metas int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		SuppressInfobars(),
+		Evaluate(state, &current),
+		// running it again has no further effect
+		SuppressInfobars(),
+		Navigate(s.URL+"/?reload"),
+		Evaluate(state, &reloaded),
+		Evaluate(`document.querySelectorAll('meta[content="notranslate"]').length`, &metas),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if current {
+		t.Error("expected the page to be marked as not to be translated")
+	}
+	if reloaded {
+		t.Error("expected the page to be marked as not to be translated after navigating")
+	}
+	if metas != 1 {
+		t.Errorf("expected a single notranslate meta tag, got: %d", metas)
+	}
+}
@@ -1,5 +1,10 @@
 package chromedp
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Error is a chromedp error.
 type Error string
 
@@ -31,9 +36,21 @@ const (
 	// ErrDisabled is the disabled error.
 	ErrDisabled Error = "disabled"
 
+	// ErrEnabled is the enabled error.
+	ErrEnabled Error = "enabled"
+
 	// ErrNotSelected is the not selected error.
 	ErrNotSelected Error = "not selected"
 
+	// ErrAttributeNotPresent is the attribute not present error.
+	ErrAttributeNotPresent Error = "attribute not present"
+
+	// ErrMediaNotReady is the media not in the expected state error.
+	ErrMediaNotReady Error = "media not ready"
+
+	// ErrAxeNotLoaded is the axe-core not loaded error.
+	ErrAxeNotLoaded Error = "axe-core not loaded"
+
 	// ErrInvalidBoxModel is the invalid box model error.
 	ErrInvalidBoxModel Error = "invalid box model"
 
@@ -46,3 +63,44 @@ const (
 	// ErrInvalidContext is the invalid context error.
 	ErrInvalidContext Error = "invalid context"
 )
+
+// ConsoleErrors is the error returned by Run when a context was created with
+// the FailOnConsoleError option, and console errors were logged or uncaught
+// exceptions were thrown while running the actions. It holds the error
+// messages, in the order they were received.
+type ConsoleErrors []string
+
+// Error satisfies the error interface.
+func (errs ConsoleErrors) Error() string {
+	return fmt.Sprintf("encountered %d console error(s): %s", len(errs), strings.Join(errs, "; "))
+}
+
+// TransactionError is the error returned by Transaction when one of its
+// actions fails, along with the diagnostics collected at that point.
+type TransactionError struct {
+	// Index is the index of the action which failed.
+	Index int
+
+	// Err is the error returned by the action.
+	Err error
+
+	// Screenshot is a PNG screenshot of the viewport.
+	Screenshot []byte
+
+	// HTML is the outer HTML of the document element.
+	HTML string
+
+	// Logs are the console messages and uncaught exceptions, each prefixed by
+	// its type, such as "log: " or "exception: ".
+	Logs []string
+}
+
+// Error satisfies the error interface.
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("transaction action %d failed: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error returned by the action which failed.
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
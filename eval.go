@@ -4,7 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
 )
 
@@ -87,6 +93,259 @@ func EvaluateAsDevTools(expression string, res interface{}, opts ...EvaluateOpti
 	return Evaluate(expression, res, append(opts, EvalObjectGroup("console"), EvalWithCommandLineAPI)...)
 }
 
+// EvaluateWithLogs is an action to evaluate the Javascript expression as
+// Evaluate does, additionally storing the messages logged to the console
+// (via console.log, console.error, and so on) while the expression was
+// evaluated in logs.
+//
+// Note: only the messages logged before the evaluation completes are
+// captured, so messages logged asynchronously (eg, from a setTimeout callback)
+// are not included, unless the expression returns a promise which is awaited.
+func EvaluateWithLogs(expression string, res interface{}, logs *[]string, opts ...EvaluateOption) EvaluateAction {
+	if logs == nil {
+		panic("logs cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var mu sync.Mutex
+		var msgs []string
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*runtime.EventConsoleAPICalled)
+			if !ok {
+				return
+			}
+			args := make([]string, len(e.Args))
+			for i, arg := range e.Args {
+				args[i] = remoteObjectString(arg)
+			}
+			mu.Lock()
+			msgs = append(msgs, strings.Join(args, " "))
+			mu.Unlock()
+		})
+
+		// Events are delivered in order, so every message logged during
+		// the evaluation has been received once its result is.
+		err := Evaluate(expression, res, opts...).Do(ctx)
+		cancel()
+
+		mu.Lock()
+		*logs = msgs
+		mu.Unlock()
+		return err
+	})
+}
+
+// ConsoleTable is an action that evaluates the Javascript expression, which
+// must produce an array of objects, or an object of objects, storing its rows
+// in rows as console.table would display them. Rows which aren't objects are
+// stored under the "Values" key, as with console.table.
+//
+// Useful to extract the tabular data computed by a page, without having to
+// declare a Go type for its rows.
+func ConsoleTable(expression string, rows *[]map[string]interface{}) EvaluateAction {
+	if rows == nil {
+		panic("rows cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var res *[]map[string]interface{}
+		if err := Evaluate(fmt.Sprintf(consoleTableJS, expression), &res).Do(ctx); err != nil {
+			return err
+		}
+		if res == nil {
+			return fmt.Errorf("expression %q did not produce an array or an object", expression)
+		}
+		*rows = *res
+		return nil
+	})
+}
+
+// WaitConsoleCount is an action that runs the during action, and then waits
+// until count messages matching pattern have been logged to the console of the
+// current target (via console.log, console.error, and so on) since during
+// started, with the arguments of each message joined by spaces, as with
+// EvaluateWithLogs.
+//
+// Useful for pages that log a marker per unit of work, such as "item
+// processed", to wait until all of the work triggered by during has been done.
+func WaitConsoleCount(during Action, pattern *regexp.Regexp, count int) Action {
+	if pattern == nil {
+		panic("pattern cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		done := make(chan struct{})
+		var mu sync.Mutex
+		seen := 0
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*runtime.EventConsoleAPICalled)
+			if !ok {
+				return
+			}
+			args := make([]string, len(e.Args))
+			for i, arg := range e.Args {
+				args[i] = remoteObjectString(arg)
+			}
+			if !pattern.MatchString(strings.Join(args, " ")) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seen++; seen == count {
+				close(done)
+				cancel()
+			}
+		})
+
+		if err := during.Do(ctx); err != nil {
+			return err
+		}
+		if count <= 0 {
+			return nil
+		}
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Deprecations is an action that retrieves the deprecation and intervention
+// warnings logged by the browser for the current page, such as the use of
+// synchronous XMLHttpRequest on the main thread, storing their text in out.
+//
+// Useful to audit a page for the use of deprecated browser APIs. As the Log
+// domain only sends the entries collected so far when it's enabled, it's
+// disabled and enabled again to retrieve them; the entries are thus sent again
+// to every listener of the target.
+func Deprecations(out *[]string) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var mu sync.Mutex
+		var msgs []string
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*log.EventEntryAdded)
+			if !ok || (e.Entry.Source != log.SourceDeprecation && e.Entry.Source != log.SourceIntervention) {
+				return
+			}
+			mu.Lock()
+			msgs = append(msgs, e.Entry.Text)
+			mu.Unlock()
+		})
+
+		if err := log.Disable().Do(ctx); err != nil {
+			return err
+		}
+		// the entries are sent before the response
+		err := log.Enable().Do(ctx)
+		cancel()
+
+		mu.Lock()
+		*out = msgs
+		mu.Unlock()
+		return err
+	})
+}
+
+// WaitFunctionDefined is an action that waits until the global property name
+// of window is defined as a function.
+//
+// Useful for pages that expose a Javascript API asynchronously, which needs to
+// be available before it can be called via Evaluate.
+func WaitFunctionDefined(name string) EvaluateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		nameJSON, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		expr := fmt.Sprintf(functionDefinedJS, nameJSON)
+
+		return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			var defined bool
+			if err := Evaluate(expr, &defined).Do(ctx); err != nil {
+				return false, err
+			}
+			return defined, nil
+		})
+	})
+}
+
+// postMessageBinding is the name of the binding used by WaitPostMessage to
+// forward message events back from the page.
+const postMessageBinding = "chromedpPostMessage"
+
+// WaitPostMessage is an action that waits until the window receives a message
+// (ie, sent via window.postMessage) whose data satisfies match, unmarshaling
+// the message data to res. A nil match accepts the first message received.
+//
+// The message hook is installed on the current document, and on any document
+// loaded while waiting, so messages sent before the action starts are not
+// seen. When res is a *[]byte, the raw JSON-encoded message data will be
+// placed in res.
+func WaitPostMessage(match func(data json.RawMessage) bool, res interface{}) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		ch := make(chan json.RawMessage, 1)
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*runtime.EventBindingCalled)
+			if !ok || e.Name != postMessageBinding {
+				return
+			}
+			data := json.RawMessage(e.Payload)
+			if match != nil && !match(data) {
+				return
+			}
+			select {
+			case ch <- data:
+				cancel()
+			default:
+			}
+		})
+
+		if err := runtime.AddBinding(postMessageBinding).Do(ctx); err != nil {
+			return err
+		}
+		hook := fmt.Sprintf(postMessageHookJS, postMessageBinding)
+		id, err := page.AddScriptToEvaluateOnNewDocument(hook).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer page.RemoveScriptToEvaluateOnNewDocument(id).Do(ctx)
+		if err := Evaluate(hook, &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+
+		var data json.RawMessage
+		select {
+		case data = <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if x, ok := res.(*[]byte); ok {
+			*x = []byte(data)
+			return nil
+		}
+		return json.Unmarshal(data, res)
+	})
+}
+
 // EvaluateOption is the type for Javascript evaluation options.
 type EvaluateOption = func(*runtime.EvaluateParams) *runtime.EvaluateParams
 
@@ -113,6 +372,18 @@ func EvalIgnoreExceptions(p *runtime.EvaluateParams) *runtime.EvaluateParams {
 	return p.WithSilent(true)
 }
 
+// evalAwaitPromise is a evaluate option that will cause the evaluation to wait
+// for the resulting promise to be resolved.
+func evalAwaitPromise(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+	return p.WithAwaitPromise(true)
+}
+
+// evalUserGesture is a evaluate option that will cause the evaluation to be
+// treated as initiated by the user, such as to start playing media.
+func evalUserGesture(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+	return p.WithUserGesture(true)
+}
+
 // EvalAsValue is a evaluate option that will cause the evaluated Javascript
 // expression to encode the result of the expression as a JSON-encoded value.
 func EvalAsValue(p *runtime.EvaluateParams) *runtime.EvaluateParams {
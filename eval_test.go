@@ -0,0 +1,173 @@
+package chromedp
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWaitFunctionDefined(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res []byte
+	if err := Run(ctx,
+		Evaluate(`setTimeout(function() {
+			window.testAPI = function() { return 'ready'; };
+		}, 100)`, &res),
+		WaitFunctionDefined("testAPI"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := Run(ctx, Evaluate(`testAPI()`, &got)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "ready"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestWaitPostMessage(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<iframe srcdoc="<script>
+		var n = 0;
+		setInterval(function() {
+			parent.postMessage({type: 'tick', n: ++n}, '*');
+		}, 20);
+	</script>"></iframe>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var msg struct {
+		Type string `json:"type"`
+		N    int    `json:"n"`
+	}
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitPostMessage(func(data json.RawMessage) bool {
+			var m struct{ N int }
+			return json.Unmarshal(data, &m) == nil && m.N >= 3
+		}, &msg),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "tick" || msg.N < 3 {
+		t.Errorf("expected a tick message with n >= 3, got: %+v", msg)
+	}
+}
+
+func TestEvaluateWithLogs(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res int
+	var logs []string
+	if err := Run(ctx,
+		Evaluate(`console.log('before')`, &[]byte{}),
+		EvaluateWithLogs(`(function() {
+			console.log('computing', 2);
+			console.warn('careful');
+			return 6 * 7;
+		})()`, &res, &logs),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if res != 42 {
+		t.Errorf("expected result 42, got: %d", res)
+	}
+	if want := []string{"computing 2", "careful"}; !reflect.DeepEqual(logs, want) {
+		t.Errorf("expected logs %q, got: %q", want, logs)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<script>
+	var xhr = new XMLHttpRequest();
+	xhr.open('GET', '/', false);
+	xhr.send();
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var deprecations []string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Deprecations(&deprecations),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(deprecations) != 1 || !strings.Contains(deprecations[0], "Synchronous XMLHttpRequest") {
+		t.Errorf("expected a synchronous XMLHttpRequest deprecation, got: %q", deprecations)
+	}
+}
+
+func TestWaitConsoleCount(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var processed int
+	if err := Run(ctx,
+		WaitConsoleCount(Evaluate(`window.processed = 0;
+		for (var i = 1; i <= 5; i++) {
+			setTimeout(function() {
+				window.processed++;
+				console.log('item processed', window.processed);
+				console.log('unrelated');
+			}, i * 50);
+		}`, &[]byte{}), regexp.MustCompile(`^item processed \d+$`), 5),
+		Evaluate(`window.processed`, &processed),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if processed != 5 {
+		t.Errorf("expected 5 processed items, got: %d", processed)
+	}
+}
+
+func TestConsoleTable(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var rows, values []map[string]interface{}
+	if err := Run(ctx,
+		ConsoleTable(`[{name: 'a', price: 1}, {name: 'b', price: 2.5}]`, &rows),
+		ConsoleTable(`({first: 'x', second: 'y'})`, &values),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []map[string]interface{}{
+		{"name": "a", "price": float64(1)},
+		{"name": "b", "price": 2.5},
+	}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected rows %v, got: %v", want, rows)
+	}
+	if want := []map[string]interface{}{{"Values": "x"}, {"Values": "y"}}; !reflect.DeepEqual(values, want) {
+		t.Errorf("expected rows %v, got: %v", want, values)
+	}
+
+	if err := Run(ctx, ConsoleTable(`42`, &rows)); err == nil {
+		t.Error("expected an error for an expression which isn't an array or an object")
+	}
+}
@@ -120,15 +120,16 @@ func ExampleNewContext_reuseBrowser() {
 	}
 
 	for i := 0; i < 2; i++ {
-		// look at the page twice, with a timeout set up; we skip
-		// cancels for the sake of brevity
-		ctx, _ := context.WithTimeout(ctx, time.Second)
-		ctx, _ = chromedp.NewContext(ctx)
+		// look at the page twice, with a timeout set up
+		tctx, cancel := context.WithTimeout(ctx, time.Second)
+		tctx, _ = chromedp.NewContext(tctx)
 		var cookies string
-		if err := chromedp.Run(ctx,
+		err := chromedp.Run(tctx,
 			chromedp.Navigate(ts.URL),
 			chromedp.Text("#cookies", &cookies),
-		); err != nil {
+		)
+		cancel()
+		if err != nil {
 			panic(err)
 		}
 		fmt.Printf("Cookies at i=%d: %q\n", i, cookies)
@@ -0,0 +1,285 @@
+package chromedp
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/fetch"
+)
+
+// MockFromDir is an action that enables the Fetch domain, and then fulfills the
+// requests made by the current target with the contents of fixture files in
+// dir. The Content-Type of each response is derived from the file extension.
+//
+// urlToFile maps the URL of each request to a slash-separated path relative to
+// dir. Requests for which urlToFile returns an empty string are continued
+// unmodified, while requests whose fixture file cannot be read are fulfilled
+// with a 404 Not Found response.
+//
+// Note: the fixtures are served until ctx is cancelled. Only one action may
+// fulfill the requests of a target at a time, so running another MockFromDir
+// meanwhile returns an error. Other actions modifying the same requests, such
+// as SetOrigin, are skipped for the requests fulfilled with a fixture.
+func MockFromDir(dir string, urlToFile func(url string) string) Action {
+	if urlToFile == nil {
+		panic("urlToFile cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageRequest,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return urlToFile(e.Request.URL) != ""
+			},
+			fulfill: func(ctx context.Context, e *fetch.EventRequestPaused) error {
+				return mockRequest(ctx, dir, urlToFile(e.Request.URL), e.RequestID)
+			},
+		})
+	})
+}
+
+// mockRequest fulfills the paused request with the contents of the named file
+// in dir.
+func mockRequest(ctx context.Context, dir, name string, id fetch.RequestID) error {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fetch.FulfillRequest(id, 404).Do(ctx)
+	}
+
+	var headers []*fetch.HeaderEntry
+	if typ := mime.TypeByExtension(filepath.Ext(path)); typ != "" {
+		headers = append(headers, &fetch.HeaderEntry{Name: "Content-Type", Value: typ})
+	}
+	return fetch.FulfillRequest(id, 200).
+		WithResponseHeaders(headers).
+		WithBody(base64.StdEncoding.EncodeToString(buf)).
+		Do(ctx)
+}
+
+// MockFromManifest is an action that enables the Fetch domain, and then
+// fulfills the requests made by the current target with the responses recorded
+// to dir by RecordToDir, with their recorded status codes and headers, so that
+// redirects, errors and cookies are replayed too. Requests for URLs which
+// weren't recorded are continued unmodified.
+//
+// Note: the responses are served until ctx is cancelled. Like MockFromDir, it
+// can't be combined with another action fulfilling the requests of the target.
+func MockFromManifest(dir string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		m, err := ReadRecordManifest(dir)
+		if err != nil {
+			return err
+		}
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageRequest,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return m[e.Request.URL] != nil
+			},
+			fulfill: func(ctx context.Context, e *fetch.EventRequestPaused) error {
+				return replayResponse(ctx, dir, m[e.Request.URL], e.RequestID)
+			},
+		})
+	})
+}
+
+// replayResponse fulfills the paused request with the recorded response.
+func replayResponse(ctx context.Context, dir string, r *RecordedResponse, id fetch.RequestID) error {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(r.File)))
+	if err != nil && !isRedirect(r.Status) {
+		return fetch.FulfillRequest(id, 404).Do(ctx)
+	}
+
+	var headers []*fetch.HeaderEntry
+	for name, value := range r.Headers {
+		for _, v := range strings.Split(value, "\n") {
+			headers = append(headers, &fetch.HeaderEntry{Name: name, Value: v})
+		}
+	}
+	return fetch.FulfillRequest(id, r.Status).
+		WithResponseHeaders(headers).
+		WithBody(base64.StdEncoding.EncodeToString(buf)).
+		Do(ctx)
+}
+
+// RecordManifestFile is the name of the manifest file written by RecordToDir.
+const RecordManifestFile = "manifest.json"
+
+// RecordedResponse is a response recorded to disk by RecordToDir.
+type RecordedResponse struct {
+	// File is the path of the file holding the response body, relative to
+	// the recording directory.
+	File string `json:"file"`
+
+	// Status is the HTTP status code of the response.
+	Status int64 `json:"status"`
+
+	// Headers are the HTTP headers of the response. The values of a header
+	// sent several times, such as Set-Cookie, are separated by newlines.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// RecordManifest maps request URLs to the responses recorded for them.
+type RecordManifest map[string]*RecordedResponse
+
+// ReadRecordManifest reads the manifest of the responses recorded to dir by
+// RecordToDir.
+func ReadRecordManifest(dir string) (RecordManifest, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, RecordManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m RecordManifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// URLToFile returns the file recorded for url, or an empty string if no
+// response was recorded for it. It can be passed to MockFromDir to replay a
+// recording.
+func (m RecordManifest) URLToFile(url string) string {
+	if r := m[url]; r != nil {
+		return r.File
+	}
+	return ""
+}
+
+// RecordToDir is an action that enables the Fetch domain, and then writes the
+// body of every response received by the current target for a URL matching
+// filter to dir, alongside a manifest of the recorded URLs, status codes and
+// headers. A nil filter records every response.
+//
+// The recording can be replayed with MockFromManifest, which serves each
+// response with its recorded status code and headers. Each body is stored under
+// a name derived from its URL, with an extension matching its content type, so
+// that it can also be served with MockFromDir, using the URLToFile method of the
+// manifest returned by ReadRecordManifest.
+//
+// Note: responses are recorded until ctx is cancelled.
+func RecordToDir(dir string, filter func(url string) bool) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		r := &recorder{dir: dir, manifest: make(RecordManifest)}
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageResponse,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return e.ResponseErrorReason == "" && (filter == nil || filter(e.Request.URL))
+			},
+			modify: func(ctx context.Context, p *pausedRequest) error {
+				// An error while recording shouldn't stop the page
+				// from loading.
+				_ = r.record(ctx, p)
+				return nil
+			},
+		})
+	})
+}
+
+// recorder writes intercepted responses and their manifest to a directory.
+type recorder struct {
+	dir string
+
+	mu       sync.Mutex
+	manifest RecordManifest
+}
+
+// record writes the body of the paused response to disk, and updates the
+// manifest.
+func (r *recorder) record(ctx context.Context, p *pausedRequest) error {
+	e := p.EventRequestPaused
+	body, err := p.responseBody(ctx)
+	if err != nil && !isRedirect(e.ResponseStatusCode) {
+		return err
+	}
+
+	headers := make(map[string]string, len(e.ResponseHeaders))
+	for _, h := range e.ResponseHeaders {
+		if v, ok := headers[h.Name]; ok {
+			headers[h.Name] = v + "\n" + h.Value
+		} else {
+			headers[h.Name] = h.Value
+		}
+	}
+	name := recordFileName(e.Request.URL, headers)
+	if err := ioutil.WriteFile(filepath.Join(r.dir, name), body, 0644); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manifest[e.Request.URL] = &RecordedResponse{
+		File:    name,
+		Status:  e.ResponseStatusCode,
+		Headers: headers,
+	}
+	buf, err := json.MarshalIndent(r.manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(r.dir, RecordManifestFile), buf, 0644)
+}
+
+// isRedirect returns whether the status code is a redirect, whose response
+// may have no body.
+func isRedirect(status int64) bool {
+	return status >= 300 && status < 400
+}
+
+// recordExtensions are the file extensions used for the bodies of the
+// recorded responses, by content type. A fixed table is used rather than the
+// system's, so that a recording gets the same file names on every system.
+var recordExtensions = map[string]string{
+	"application/javascript": ".js",
+	"application/json":       ".json",
+	"application/pdf":        ".pdf",
+	"application/wasm":       ".wasm",
+	"application/xml":        ".xml",
+	"font/woff":              ".woff",
+	"font/woff2":             ".woff2",
+	"image/gif":              ".gif",
+	"image/jpeg":             ".jpg",
+	"image/png":              ".png",
+	"image/svg+xml":          ".svg",
+	"image/webp":             ".webp",
+	"image/x-icon":           ".ico",
+	"text/css":               ".css",
+	"text/html":              ".html",
+	"text/javascript":        ".js",
+	"text/plain":             ".txt",
+	"text/xml":               ".xml",
+}
+
+// recordFileName returns the name of the file used to record the response for
+// rawurl. The extension is derived from the Content-Type header via
+// recordExtensions, so that MockFromDir serves the file with the same content
+// type, falling back to ".bin".
+func recordFileName(rawurl string, headers map[string]string) string {
+	sum := sha1.Sum([]byte(rawurl))
+	name := hex.EncodeToString(sum[:])
+
+	for k, v := range headers {
+		if !strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		if typ, _, err := mime.ParseMediaType(v); err == nil {
+			if ext, ok := recordExtensions[typ]; ok {
+				return name + ext
+			}
+		}
+	}
+	return name + ".bin"
+}
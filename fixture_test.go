@@ -0,0 +1,200 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestMockFromDir(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "chromedp-mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"index.html": `<html><body><div id="out"></div><script>
+			fetch('/api/data.json').then(function(r) { return r.json(); }).then(function(d) {
+				document.getElementById('out').textContent = d.message;
+			});
+		</script></body></html>`,
+		"api/data.json": `{"message": "mocked"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var text string
+	if err := Run(ctx,
+		MockFromDir(dir, func(url string) string {
+			path := strings.TrimPrefix(url, "http://mock.test/")
+			if path == url {
+				return ""
+			}
+			if path == "" {
+				return "index.html"
+			}
+			return path
+		}),
+		Navigate("http://mock.test/"),
+		WaitReady(`#out:not(:empty)`, ByQuery),
+		Text(`#out`, &text, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "mocked"; text != want {
+		t.Errorf("expected text %q, got: %q", want, text)
+	}
+}
+
+func TestRecordToDir(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<div id="out"></div><script>
+		fetch('/data.json').then(function(r) { return r.json(); }).then(function(d) {
+			document.getElementById('out').textContent = d.message;
+		});
+	</script>`))
+	mux.HandleFunc("/data.json", func(res http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(res, &http.Cookie{Name: "recorded", Value: "1"})
+		http.SetCookie(res, &http.Cookie{Name: "replayed", Value: "1"})
+		res.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(res, `{"message": "recorded"}`)
+	})
+	mux.HandleFunc("/missing", func(res http.ResponseWriter, _ *http.Request) {
+		http.Error(res, "missing", http.StatusNotFound)
+	})
+	s := httptest.NewServer(mux)
+
+	dir, err := ioutil.TempDir("", "chromedp-record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx,
+		RecordToDir(dir, func(url string) bool {
+			return strings.HasPrefix(url, s.URL)
+		}),
+		Navigate(s.URL),
+		WaitReady(`#out:not(:empty)`, ByQuery),
+		Evaluate(`fetch('/missing').then(function(r) { return r.status; })`, &[]byte{}, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	m, err := ReadRecordManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := m[s.URL+"/data.json"]
+	if r == nil {
+		t.Fatalf("expected a recorded response for data.json, got: %v", m)
+	}
+	if r.Status != 200 {
+		t.Errorf("expected status 200, got: %d", r.Status)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(dir, r.File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"message": "recorded"}`; string(buf) != want {
+		t.Errorf("expected body %q, got: %q", want, buf)
+	}
+
+	// replay the recording in a new tab, with the server gone
+	tctx, cancel := NewContext(ctx)
+	defer cancel()
+
+	var text string
+	if err := Run(tctx,
+		MockFromDir(dir, m.URLToFile),
+		Navigate(s.URL),
+		WaitReady(`#out:not(:empty)`, ByQuery),
+		Text(`#out`, &text, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "recorded"; text != want {
+		t.Errorf("expected text %q, got: %q", want, text)
+	}
+
+	// replay the statuses and headers too, with the cookies cleared
+	mctx, cancel := NewContext(ctx)
+	defer cancel()
+
+	var status int
+	var cookies []string
+	if err := Run(mctx,
+		network.ClearBrowserCookies(),
+		MockFromManifest(dir),
+		Navigate(s.URL),
+		WaitReady(`#out:not(:empty)`, ByQuery),
+		Evaluate(`fetch('/missing').then(function(r) { return r.status; })`, &status, evalAwaitPromise),
+		ActionFunc(func(ctx context.Context) error {
+			all, err := network.GetAllCookies().Do(ctx)
+			for _, c := range all {
+				cookies = append(cookies, c.Name)
+			}
+			return err
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if status != 404 {
+		t.Errorf("expected the recorded status 404, got: %d", status)
+	}
+	sort.Strings(cookies)
+	if want := []string{"recorded", "replayed"}; !reflect.DeepEqual(cookies, want) {
+		t.Errorf("expected the recorded cookies %q, got: %q", want, cookies)
+	}
+}
+
+func TestRecordFileName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/json; charset=utf-8", ".json"},
+		{"text/html", ".html"},
+		{"image/jpeg", ".jpg"},
+		{"application/x-unknown", ".bin"},
+		{"", ".bin"},
+	}
+	for _, test := range tests {
+		headers := map[string]string{"content-type": test.contentType}
+		if got := path.Ext(recordFileName("https://example.com/data", headers)); got != test.want {
+			t.Errorf("expected extension %q for %q, got: %q", test.want, test.contentType, got)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp/kb"
 )
 
@@ -142,6 +143,23 @@ func ClickCount(n int) MouseOption {
 	}
 }
 
+// RenderCursor is an action that renders a mouse cursor in the current
+// document, and in any document loaded afterwards, at the position of the last
+// mouse event, such as the ones dispatched by MouseClickXY; the cursor is
+// hidden until the first one. Headless browsers don't render the cursor, so
+// this is useful to show where the pointer is in screenshots and screencasts.
+//
+// The cursor is an element appended to the document, which ignores pointer
+// events and is rendered on top of the page.
+func RenderCursor() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if _, err := page.AddScriptToEvaluateOnNewDocument(cursorJS).Do(ctx); err != nil {
+			return err
+		}
+		return Evaluate(cursorJS, &[]byte{}).Do(ctx)
+	})
+}
+
 // KeyAction are keyboard (key) input event actions.
 type KeyAction Action
 
@@ -194,3 +212,101 @@ func KeyModifiers(modifiers ...input.Modifier) KeyOption {
 		return p
 	}
 }
+
+// TabOrder is an action that presses the Tab key repeatedly, starting from the
+// beginning of the current document, storing the element nodes which got the
+// focus in order in out. Useful to audit the keyboard navigation of a page.
+//
+// The sequence ends when the focus leaves the document's elements after the
+// last one, or when an element gets the focus a second time, such as within
+// a focus trap. The focus is left on the last element. The focus is followed
+// into same-origin iframes, reporting the elements focused within them.
+//
+// Note: out-of-process iframes, such as cross-origin ones, belong to separate
+// targets, so an element focused within one is reported as the iframe element,
+// and the sequence ends once a second element within it gets the focus.
+//
+// Note: any element with the focus is blurred first, so that the navigation
+// starts from the beginning of the document; focusing an element, or clicking
+// on the page, beforehand may move the point it starts from.
+func TabOrder(out *[]*cdp.Node) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := Evaluate(`document.activeElement && document.activeElement.blur()`, &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+
+		var order []*cdp.Node
+		seen := make(map[cdp.NodeID]bool)
+		for {
+			if err := KeyEvent(kb.Tab).Do(ctx); err != nil {
+				return err
+			}
+			nodes, err := evaluateNodes(ctx, focusedJS)
+			if err != nil {
+				return err
+			}
+			if len(nodes) == 0 || seen[nodes[0].NodeID] {
+				break
+			}
+			n := nodes[0]
+			seen[n.NodeID] = true
+			order = append(order, n)
+		}
+		*out = order
+		return nil
+	})
+}
+
+// FocusableElements is an action that retrieves the element nodes of the
+// current document which can receive the keyboard focus, in document order,
+// storing them in out: links, form controls, editable elements, and elements
+// with a non-negative tabindex. Disabled, hidden and inert elements, and the
+// ones with a negative tabindex, are left out.
+//
+// Useful to audit a page for controls missing from the keyboard navigation,
+// or for elements which shouldn't be part of it. Unlike TabOrder, the focus
+// isn't moved, and the order is the document order rather than the tab order.
+func FocusableElements(out *[]*cdp.Node) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		nodes, err := evaluateNodes(ctx, focusableJS)
+		if err != nil {
+			return err
+		}
+		*out = nodes
+		return nil
+	})
+}
+
+// HoveredElement is an action that retrieves the innermost element node of the
+// current document in the :hover state, which is the element the mouse is over
+// after the last mouse event dispatched to the page, storing it in node. node
+// is set to nil when no element is hovered. Elements hovered within an iframe
+// are reported as the iframe element.
+//
+// Useful to debug menus and tooltips depending on the hover state, such as a
+// dropdown closing unexpectedly.
+func HoveredElement(node **cdp.Node) Action {
+	if node == nil {
+		panic("node cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		nodes, err := evaluateNodes(ctx, hoveredJS)
+		if err != nil {
+			return err
+		}
+		*node = nil
+		if len(nodes) > 0 {
+			*node = nodes[0]
+		}
+		return nil
+	})
+}
@@ -1,7 +1,11 @@
 package chromedp
 
 import (
+	"bytes"
 	"fmt"
+	"image/png"
+	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -268,3 +272,189 @@ func TestKeyEventNode(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderCursor(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<body style="background: white"></body>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// dark checks whether the pixel within the cursor's arrow is dark.
+	dark := func(buf []byte) bool {
+		img, err := png.Decode(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, g, b, _ := img.At(32, 47).RGBA()
+		return r < 0x4000 && g < 0x4000 && b < 0x4000
+	}
+
+	var before, after, reloaded []byte
+	if err := Run(ctx,
+		EmulateViewport(200, 200),
+		Navigate(s.URL),
+		RenderCursor(),
+		CaptureScreenshot(&before),
+		MouseEvent(input.MouseMoved, 30, 40),
+		CaptureScreenshot(&after),
+		Reload(),
+		CaptureScreenshot(&reloaded),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if dark(before) {
+		t.Error("expected no cursor before the first mouse event")
+	}
+	if !dark(after) {
+		t.Error("expected the cursor to be rendered at the mouse position")
+	}
+	if !dark(reloaded) {
+		t.Error("expected the cursor to be rendered at the same position after reloading")
+	}
+}
+
+func TestTabOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{"Page", `
+<input id="a">
+<button id="skipped" tabindex="-1">skipped</button>
+<button id="b">b</button>
+<input id="disabled" disabled>
+<a id="c" href="#">c</a>
+<div id="first" tabindex="1">first</div>
+<div>not focusable</div>
+		`, []string{"first", "a", "b", "c"}},
+		{"Trap", `
+<input id="a">
+<div id="dialog">
+	<input id="b">
+	<button id="c">c</button>
+</div>
+<input id="d">
+<script>
+	var b = document.getElementById('b'), c = document.getElementById('c');
+	c.addEventListener('keydown', function(e) {
+		if (e.key === 'Tab') {
+			e.preventDefault();
+			b.focus();
+		}
+	});
+</script>
+		`, []string{"a", "b", "c"}},
+		{"Frame", `
+<input id="a">
+<iframe srcdoc="<input id='b'><button id='c'>c</button>"></iframe>
+<input id="d">
+		`, []string{"a", "b", "c", "d"}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := httptest.NewServer(writeHTML(test.html))
+			defer s.Close()
+
+			ctx, cancel := testAllocate(t, "")
+			defer cancel()
+
+			var nodes []*cdp.Node
+			if err := Run(ctx,
+				Navigate(s.URL),
+				TabOrder(&nodes),
+			); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, n := range nodes {
+				ids = append(ids, n.AttributeValue("id"))
+			}
+			if !reflect.DeepEqual(ids, test.want) {
+				t.Errorf("expected tab order %v, got: %v", test.want, ids)
+			}
+		})
+	}
+}
+
+func TestFocusableElements(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<div id="first" tabindex="1">first</div>
+<input id="a">
+<input id="hidden-input" type="hidden">
+<button id="skipped" tabindex="-1">skipped</button>
+<fieldset disabled><button id="disabled">disabled</button></fieldset>
+<a id="b" href="#">b</a>
+<a id="no-href">no href</a>
+<button id="c" style="display: none">c</button>
+<div id="d" contenteditable>d</div>
+<div inert><input id="inert"></div>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var nodes []*cdp.Node
+	if err := Run(ctx,
+		Navigate(s.URL),
+		FocusableElements(&nodes),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, n.AttributeValue("id"))
+	}
+	if want := []string{"first", "a", "b", "d"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected focusable elements %v, got: %v", want, ids)
+	}
+}
+
+func TestHoveredElement(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<style>
+	#menu { position: absolute; left: 0; top: 0; width: 200px; height: 100px; }
+	#item { position: absolute; left: 10px; top: 10px; width: 50px; height: 20px; }
+</style>
+<nav id="menu"><a id="item" href="#">item</a></nav>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var none, item, menu *cdp.Node
+	if err := Run(ctx,
+		Navigate(s.URL),
+		MouseEvent(input.MouseMoved, 500, 500),
+		HoveredElement(&none),
+		MouseEvent(input.MouseMoved, 20, 20),
+		HoveredElement(&item),
+		MouseEvent(input.MouseMoved, 150, 50),
+		HoveredElement(&menu),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if none != nil && none.LocalName != "html" && none.LocalName != "body" {
+		t.Errorf("expected no element to be hovered, got: %s", none.LocalName)
+	}
+	if item == nil || item.AttributeValue("id") != "item" {
+		t.Errorf("expected the item to be hovered, got: %v", item)
+	}
+	if menu == nil || menu.AttributeValue("id") != "menu" {
+		t.Errorf("expected the menu to be hovered, got: %v", menu)
+	}
+}
@@ -35,6 +35,21 @@ const (
 		return true;
 	})(%s)`
 
+	// selectTextJS is a javascript snippet that selects the text of the
+	// specified element, within the field itself for text fields.
+	selectTextJS = `(function(a) {
+		if (a instanceof HTMLInputElement || a instanceof HTMLTextAreaElement) {
+			a.focus();
+			a.select();
+			return;
+		}
+		var range = document.createRange();
+		range.selectNodeContents(a);
+		var selection = window.getSelection();
+		selection.removeAllRanges();
+		selection.addRange(range);
+	})(%s)`
+
 	// scrollIntoViewJS is a javascript snippet that scrolls the specified node
 	// into the window's viewport (if needed), returning the actual window x/y
 	// after execution.
@@ -43,6 +58,36 @@ const (
 		return [window.scrollX, window.scrollY];
 	})(%s)`
 
+	// pinIntoViewJS is a javascript snippet that instantly scrolls the window
+	// so that the element is at the top of the viewport, and then keeps the
+	// window at that position by scrolling it back whenever it's scrolled,
+	// until unpinViewJS is run. It returns the pinned scroll position.
+	pinIntoViewJS = `(function(el) {
+		var key = '__chromedpPin';
+		if (window[key]) {
+			window.removeEventListener('scroll', window[key].restore, true);
+		}
+		el.scrollIntoView({block: 'start', inline: 'nearest', behavior: 'instant'});
+		var pin = window[key] = {x: window.scrollX, y: window.scrollY};
+		pin.restore = function() {
+			if (window.scrollX !== pin.x || window.scrollY !== pin.y) {
+				window.scrollTo({left: pin.x, top: pin.y, behavior: 'instant'});
+			}
+		};
+		window.addEventListener('scroll', pin.restore, true);
+		return [pin.x, pin.y];
+	})(%s)`
+
+	// unpinViewJS is a javascript snippet that releases the scroll position
+	// pinned by pinIntoViewJS, if any.
+	unpinViewJS = `(function() {
+		var key = '__chromedpPin';
+		if (window[key]) {
+			window.removeEventListener('scroll', window[key].restore, true);
+			delete window[key];
+		}
+	})()`
+
 	// submitJS is a javascript snippet that will call the containing form's
 	// submit function, returning true or false if the call was successful.
 	submitJS = `(function(a) {
@@ -69,6 +114,24 @@ const (
 		return false;
 	})(%s)`
 
+	// reportValidityJS is a javascript snippet that reports the validity of
+	// the containing form, returning whether it is valid along with the
+	// validation messages of its invalid fields, keyed by their name or id, or
+	// null if there is no form.
+	reportValidityJS = `(function(a) {
+		var form = a.nodeName === 'FORM' ? a : a.form;
+		if (!form) {
+			return null;
+		}
+		var messages = {};
+		Array.prototype.forEach.call(form.elements, function(el, i) {
+			if (el.willValidate && el.validationMessage) {
+				messages[el.name || el.id || String(i)] = el.validationMessage;
+			}
+		});
+		return {valid: form.reportValidity(), messages: messages};
+	})(%s)`
+
 	// attributeJS is a javascript snippet that returns the attribute of a specified
 	// node.
 	attributeJS = `(function(a, n) {
@@ -87,6 +150,1484 @@ const (
 	visibleJS = `(function(a) {
 		return Boolean( a.offsetWidth || a.offsetHeight || a.getClientRects().length );
 	})(%s)`
+
+	// functionDefinedJS is a javascript snippet that returns true or false
+	// depending on if the specified property of window is a function.
+	functionDefinedJS = `(function(n) {
+		return typeof window[n] === 'function';
+	})(%s)`
+
+	// linksJS is a javascript snippet that returns the href values of all the
+	// document's anchor elements, optionally resolved to absolute URLs and
+	// filtered to the document's origin.
+	linksJS = `(function(absolute, sameOrigin) {
+		var links = [];
+		var a = document.querySelectorAll('a[href]');
+		for (var i = 0; i < a.length; i++) {
+			if (sameOrigin && a[i].origin !== location.origin) {
+				continue;
+			}
+			links.push(absolute ? a[i].href : a[i].getAttribute('href'));
+		}
+		return links;
+	})(%t, %t)`
+
+	// metaTagsJS is a javascript snippet that returns the content of the
+	// document's meta elements, keyed by their name, property or http-equiv
+	// attribute.
+	metaTagsJS = `(function() {
+		var tags = {};
+		var m = document.querySelectorAll('meta[content]');
+		for (var i = 0; i < m.length; i++) {
+			var key = m[i].getAttribute('name') || m[i].getAttribute('property') || m[i].getAttribute('http-equiv');
+			if (key) {
+				tags[key] = m[i].getAttribute('content');
+			}
+		}
+		return tags;
+	})()`
+
+	// jsonLDJS is a javascript snippet that returns the text of the document's
+	// JSON-LD script elements.
+	jsonLDJS = `(function() {
+		var blocks = [];
+		var s = document.querySelectorAll('script[type="application/ld+json"]');
+		for (var i = 0; i < s.length; i++) {
+			blocks.push(s[i].textContent);
+		}
+		return blocks;
+	})()`
+
+	// faviconJS is a javascript snippet that returns a promise resolving to
+	// the base64 encoded content of the document's favicon, as declared by its
+	// first <link rel="icon"> element or falling back to /favicon.ico.
+	faviconJS = `(function() {
+		var link = document.querySelector('link[rel~="icon" i][href]');
+		var url = link ? link.href : new URL('/favicon.ico', document.baseURI).href;
+		return fetch(url, {credentials: 'include'}).then(function(res) {
+			if (!res.ok) {
+				throw new Error('could not fetch favicon ' + url + ': ' + res.status);
+			}
+			return res.arrayBuffer();
+		}).then(function(buf) {
+			var bytes = new Uint8Array(buf), s = '';
+			for (var i = 0; i < bytes.length; i++) {
+				s += String.fromCharCode(bytes[i]);
+			}
+			return btoa(s);
+		});
+	})()`
+
+	// inlineHTMLJS is a javascript snippet that returns a promise resolving
+	// to the serialized document, with its stylesheets, images and the
+	// resources referenced by its CSS, such as fonts, inlined as data URLs,
+	// and its scripts removed. Resources which can't be fetched are left as
+	// absolute URLs.
+	inlineHTMLJS = `(function() {
+		var cache = {};
+		function absolute(url, base) {
+			try {
+				return new URL(url, base).href;
+			} catch (e) {
+				return url;
+			}
+		}
+		function fetchOK(url) {
+			return fetch(url, {credentials: 'include'}).then(function(res) {
+				if (!res.ok) {
+					throw new Error(res.statusText);
+				}
+				return res;
+			});
+		}
+		function dataURL(url) {
+			if (/^(data|blob|about|javascript):/i.test(url)) {
+				return Promise.resolve(url);
+			}
+			if (!cache[url]) {
+				cache[url] = fetchOK(url).then(function(res) {
+					return res.blob();
+				}).then(function(blob) {
+					return new Promise(function(resolve, reject) {
+						var r = new FileReader();
+						r.onload = function() { resolve(r.result); };
+						r.onerror = function() { reject(r.error); };
+						r.readAsDataURL(blob);
+					});
+				}).catch(function() {
+					return url;
+				});
+			}
+			return cache[url];
+		}
+		// replace replaces the matches of re in s with the results of the
+		// promises returned by f.
+		function replace(s, re, f) {
+			var parts = [];
+			s.replace(re, function() {
+				parts.push(f.apply(null, arguments));
+			});
+			return Promise.all(parts).then(function(values) {
+				var i = 0;
+				return s.replace(re, function() {
+					return values[i++];
+				});
+			});
+		}
+		function inlineCSS(css, base) {
+			var importRe = /@import\s+(?:url\(\s*)?(['"]?)([^'")\s]+)\1\s*\)?\s*([^;]*);/g;
+			return replace(css, importRe, function(m, q, url, media) {
+				url = absolute(url, base);
+				return fetchOK(url).then(function(res) {
+					return res.text();
+				}).then(function(text) {
+					return inlineCSS(text, url);
+				}).then(function(text) {
+					return media.trim() ? '@media ' + media + ' {\n' + text + '\n}' : text;
+				}).catch(function() {
+					return '@import url("' + url + '") ' + media + ';';
+				});
+			}).then(function(css) {
+				return replace(css, /url\(\s*(['"]?)([^'")]+)\1\s*\)/g, function(m, q, url) {
+					return dataURL(absolute(url, base)).then(function(data) {
+						return 'url("' + data + '")';
+					});
+				});
+			});
+		}
+
+		var base = document.baseURI;
+		var root = document.documentElement.cloneNode(true);
+		var work = [];
+
+		// the cloned images are in the same order as the original ones
+		var imgs = document.querySelectorAll('img');
+		root.querySelectorAll('img').forEach(function(img, i) {
+			var src = imgs[i] && imgs[i].currentSrc || img.src;
+			img.removeAttribute('srcset');
+			img.removeAttribute('sizes');
+			if (src) {
+				work.push(dataURL(src).then(function(data) { img.setAttribute('src', data); }));
+			}
+		});
+		root.querySelectorAll('picture source, script, base').forEach(function(el) {
+			el.remove();
+		});
+		root.querySelectorAll('link[href]').forEach(function(link) {
+			var href = absolute(link.getAttribute('href'), base);
+			link.setAttribute('href', href);
+			if (/(^|\s)stylesheet(\s|$)/i.test(link.rel)) {
+				work.push(fetchOK(href).then(function(res) {
+					return res.text();
+				}).then(function(text) {
+					return inlineCSS(text, href);
+				}).then(function(css) {
+					var style = document.createElement('style');
+					if (link.media) {
+						style.media = link.media;
+					}
+					style.textContent = css;
+					link.replaceWith(style);
+				}).catch(function() {}));
+			} else if (/(^|\s)icon(\s|$)/i.test(link.rel)) {
+				work.push(dataURL(href).then(function(data) { link.setAttribute('href', data); }));
+			}
+		});
+		root.querySelectorAll('style').forEach(function(style) {
+			work.push(inlineCSS(style.textContent, base).then(function(css) { style.textContent = css; }));
+		});
+		root.querySelectorAll('[style]').forEach(function(el) {
+			work.push(inlineCSS(el.getAttribute('style'), base).then(function(css) { el.setAttribute('style', css); }));
+		});
+		root.querySelectorAll('[href], [src], [action], [poster]').forEach(function(el) {
+			['href', 'src', 'action', 'poster'].forEach(function(name) {
+				var v = el.getAttribute(name);
+				if (v !== null && !/^data:/i.test(v) && el.localName !== 'img' && el.localName !== 'link') {
+					el.setAttribute(name, absolute(v, base));
+				}
+			});
+		});
+		// serialize the state of the form fields, which isn't reflected by
+		// their attributes
+		var fields = document.querySelectorAll('input, textarea, option');
+		root.querySelectorAll('input, textarea, option').forEach(function(el, i) {
+			var orig = fields[i];
+			if (el.localName === 'textarea') {
+				el.textContent = orig.value;
+			} else if (el.localName === 'option') {
+				el.toggleAttribute('selected', orig.selected);
+			} else if (el.type === 'checkbox' || el.type === 'radio') {
+				el.toggleAttribute('checked', orig.checked);
+			} else if (el.type !== 'file' && el.type !== 'password') {
+				el.setAttribute('value', orig.value);
+			}
+		});
+
+		return Promise.all(work).then(function() {
+			var doctype = document.doctype ? new XMLSerializer().serializeToString(document.doctype) + '\n' : '';
+			return doctype + root.outerHTML;
+		});
+	})()`
+
+	// pageStableJS is a javascript snippet that returns a promise resolving
+	// once the document's fonts have loaded, and all of its finite animations
+	// have finished.
+	pageStableJS = `document.fonts.ready.then(function() {
+		var animations = document.getAnimations().filter(function(a) {
+			return a.playState === 'running' && a.effect && a.effect.getComputedTiming().endTime !== Infinity;
+		});
+		return Promise.all(animations.map(function(a) {
+			return a.finished.catch(function() {});
+		}));
+	}).then(function() {
+		return true;
+	})`
+
+	// bfcacheHookJS is a javascript snippet that records in the window
+	// whether the page is shown again after being restored from the
+	// back/forward cache.
+	bfcacheHookJS = `window.addEventListener('pageshow', function(e) {
+		window.__chromedpPersisted = e.persisted;
+	})`
+
+	// bfcacheRestoredJS is a javascript snippet that returns a promise
+	// resolving to whether the loaded page was restored from the back/forward
+	// cache, as recorded by bfcacheHookJS. A page loaded again from scratch
+	// doesn't have the hook.
+	bfcacheRestoredJS = `new Promise(function(resolve) {
+		function check() {
+			resolve(window.__chromedpPersisted === true);
+		}
+		if (document.readyState === 'complete') {
+			check();
+		} else {
+			window.addEventListener('load', check);
+		}
+	})`
+
+	// documentLoadedJS is a javascript snippet that returns a promise
+	// resolving once the document and all of its resources have loaded.
+	documentLoadedJS = `new Promise(function(resolve) {
+		if (document.readyState === 'complete') {
+			resolve(true);
+		} else {
+			window.addEventListener('load', function() {
+				resolve(true);
+			});
+		}
+	})`
+
+	// eagerImagesJS is a javascript snippet that switches every element of
+	// the document which is lazily loaded to be loaded eagerly, including the
+	// elements added or modified later on. It's only installed once per
+	// window.
+	eagerImagesJS = `(function() {
+		var key = '__chromedpEagerImages';
+		if (window[key]) {
+			return;
+		}
+		window[key] = true;
+		function eager(root) {
+			if (root.getAttribute && root.getAttribute('loading') === 'lazy') {
+				root.setAttribute('loading', 'eager');
+			}
+			if (root.querySelectorAll) {
+				var els = root.querySelectorAll('[loading="lazy"]');
+				for (var i = 0; i < els.length; i++) {
+					els[i].setAttribute('loading', 'eager');
+				}
+			}
+		}
+		new MutationObserver(function(records) {
+			records.forEach(function(r) {
+				if (r.type === 'attributes') {
+					eager(r.target);
+				} else {
+					r.addedNodes.forEach(eager);
+				}
+			});
+		}).observe(document, {childList: true, subtree: true, attributes: true, attributeFilter: ['loading']});
+		eager(document);
+	})()`
+
+	// scrollLoadJS is a javascript snippet that returns a promise resolving
+	// once the document has been scrolled through to its end, one viewport at
+	// a time, to trigger scroll based lazy loading, and all of its images
+	// have then finished loading. The scroll position is restored afterwards.
+	scrollLoadJS = `(function() {
+		var x = window.scrollX, y = window.scrollY;
+		var el = document.scrollingElement || document.documentElement;
+		var top = 0, steps = 0;
+		function step() {
+			window.scrollTo(0, top);
+			return new Promise(function(resolve) {
+				requestAnimationFrame(function() { setTimeout(resolve, 50); });
+			}).then(function() {
+				top += window.innerHeight;
+				// don't scroll forever through infinitely scrolling pages
+				if (top < el.scrollHeight && ++steps < 100) {
+					return step();
+				}
+			});
+		}
+		return step().then(function() {
+			window.scrollTo(x, y);
+			return Promise.all(Array.prototype.map.call(document.images, function(img) {
+				if (img.complete) {
+					return;
+				}
+				return new Promise(function(resolve) {
+					img.addEventListener('load', resolve);
+					img.addEventListener('error', resolve);
+				});
+			}));
+		}).then(function() {
+			return true;
+		});
+	})()`
+
+	// historyStateJS is a javascript snippet that calls the specified method
+	// of the History API (ie, pushState or replaceState) with the specified
+	// state and URL, keeping the current URL when it's empty.
+	historyStateJS = `(function(method, state, url) {
+		history[method](state, '', url || undefined);
+	})(%q, %s, %q)`
+
+	// dispatchPopStateJS is a javascript snippet that dispatches a popstate
+	// event with the current history state on the window.
+	dispatchPopStateJS = `(function() {
+		window.dispatchEvent(new PopStateEvent('popstate', {state: history.state}));
+	})()`
+
+	// setBaseURLJS is a javascript snippet that sets the href of the first
+	// base element of the document to the specified URL, inserting the
+	// element at the start of the head when there's none.
+	setBaseURLJS = `(function(url) {
+		var base = document.querySelector('base');
+		if (!base) {
+			base = document.createElement('base');
+			var parent = document.head || document.documentElement;
+			parent.insertBefore(base, parent.firstChild);
+		}
+		base.href = url;
+	})(%s)`
+
+	// titleChangeJS is a javascript snippet that returns the document title as
+	// soon as it is different from the specified title, using a
+	// MutationObserver to wait for the document title to change.
+	titleChangeJS = `(function(prev) {
+		if (document.title !== prev) {
+			return document.title;
+		}
+		return new Promise(function(resolve) {
+			var observer = new MutationObserver(function() {
+				if (document.title !== prev) {
+					observer.disconnect();
+					resolve(document.title);
+				}
+			});
+			observer.observe(document, {subtree: true, childList: true, characterData: true});
+		});
+	})(%q)`
+
+	// compositionStartJS is a javascript snippet that dispatches the
+	// compositionstart event on the specified node, followed by a
+	// compositionupdate event for each of the specified composition strings.
+	compositionStartJS = `(function(a, updates) {
+		a.dispatchEvent(new CompositionEvent('compositionstart', {bubbles: true, cancelable: true, data: ''}));
+		for (var i = 0; i < updates.length; i++) {
+			a.dispatchEvent(new CompositionEvent('compositionupdate', {bubbles: true, cancelable: true, data: updates[i]}));
+		}
+		return true;
+	})(%s, %s)`
+
+	// compositionEndJS is a javascript snippet that dispatches the
+	// compositionend event on the specified node, with the specified data.
+	compositionEndJS = `(function(a, data) {
+		a.dispatchEvent(new CompositionEvent('compositionend', {bubbles: true, cancelable: true, data: data}));
+		return true;
+	})(%s, %s)`
+
+	// windowSizeJS is a javascript snippet that returns the window's inner and
+	// outer sizes.
+	windowSizeJS = `[window.innerWidth, window.innerHeight, window.outerWidth, window.outerHeight]`
+
+	// textBoxJS is a javascript snippet that returns the bounding rectangle of
+	// the first occurrence of the specified text within a visible text node of
+	// the document, or null if there is none.
+	textBoxJS = `(function(text) {
+		var walker = document.createTreeWalker(document.body, NodeFilter.SHOW_TEXT, null, false);
+		var range = document.createRange();
+		while (walker.nextNode()) {
+			var node = walker.currentNode;
+			for (var i = node.data.indexOf(text); i !== -1; i = node.data.indexOf(text, i + 1)) {
+				range.setStart(node, i);
+				range.setEnd(node, i + text.length);
+				var r = range.getBoundingClientRect();
+				if (r.width > 0 && r.height > 0) {
+					return {x: r.x, y: r.y, width: r.width, height: r.height};
+				}
+			}
+		}
+		return null;
+	})(%s)`
+
+	// postMessageHookJS is a javascript snippet that forwards the data of
+	// every message event received by the window to the specified binding,
+	// encoded as JSON. The hook is only installed once per window.
+	postMessageHookJS = `(function(binding) {
+		var key = '__' + binding + 'Hooked';
+		if (window[key]) {
+			return;
+		}
+		window[key] = true;
+		window.addEventListener('message', function(e) {
+			if (typeof window[binding] === 'function') {
+				window[binding](JSON.stringify(e.data === undefined ? null : e.data));
+			}
+		});
+	})(%q)`
+
+	// cssVariablesJS is a javascript snippet that returns the resolved values
+	// of the custom properties in scope for the element. As computed styles
+	// don't necessarily enumerate custom properties, their names are collected
+	// from the declarations of the readable stylesheets, including the ones
+	// nested in grouping rules, and from the inline styles of the element and
+	// its ancestors.
+	cssVariablesJS = `(function(el) {
+		var names = {};
+		function collect(style) {
+			for (var i = 0; i < style.length; i++) {
+				if (style[i].indexOf('--') === 0) {
+					names[style[i]] = true;
+				}
+			}
+		}
+		function walk(rules) {
+			Array.prototype.forEach.call(rules, function(rule) {
+				if (rule.style) {
+					collect(rule.style);
+				}
+				if (rule.cssRules) {
+					walk(rule.cssRules);
+				}
+			});
+		}
+		Array.prototype.forEach.call(document.styleSheets, function(sheet) {
+			try {
+				walk(sheet.cssRules);
+			} catch (e) {
+				// cross-origin stylesheets can't be read
+			}
+		});
+		for (var node = el; node && node.style; node = node.parentElement) {
+			collect(node.style);
+		}
+		var style = window.getComputedStyle(el);
+		collect(style);
+		var vars = {};
+		Object.keys(names).forEach(function(name) {
+			var value = style.getPropertyValue(name).trim();
+			if (value !== '') {
+				vars[name] = value;
+			}
+		});
+		return vars;
+	})(%s)`
+
+	// animationsFinishedJS is a javascript snippet that returns a promise
+	// resolving once the animations and transitions of the element and its
+	// descendants have finished, checking again for animations started in the
+	// meantime. Infinite animations are ignored, and cancelled ones are
+	// considered finished.
+	animationsFinishedJS = `(function(el) {
+		function pending() {
+			return el.getAnimations({subtree: true}).filter(function(a) {
+				return a.playState !== 'finished' && a.effect &&
+					a.effect.getComputedTiming().iterations !== Infinity;
+			});
+		}
+		function wait() {
+			var animations = pending();
+			if (animations.length === 0) {
+				return Promise.resolve(true);
+			}
+			return Promise.all(animations.map(function(a) {
+				return a.finished.catch(function() {});
+			})).then(wait);
+		}
+		return wait();
+	})(%s)`
+
+	// computedStylePropertyJS is a javascript snippet that returns the value of
+	// a property of the element's computed style. The property may be named in
+	// either its CSS form, such as "background-color", or its camel case
+	// form, such as "backgroundColor".
+	computedStylePropertyJS = `(function(el, property) {
+		var style = window.getComputedStyle(el);
+		return style.getPropertyValue(property) || String(style[property] || '');
+	})(%s, %q)`
+
+	// normalizedDOMJS is a javascript snippet that serializes the structure of
+	// the document to JSON, leaving out comments, scripts, whitespace-only text
+	// and the attributes named in the given array, collapsing whitespace and
+	// sorting attributes.
+	normalizedDOMJS = `(function(ignore) {
+		function normalize(node) {
+			if (node.nodeType === Node.TEXT_NODE) {
+				var text = node.nodeValue.replace(/\s+/g, ' ').trim();
+				return text === '' ? null : text;
+			}
+			if (node.nodeType !== Node.ELEMENT_NODE || node.localName === 'script' || node.localName === 'noscript') {
+				return null;
+			}
+			var attrs = Array.prototype.filter.call(node.attributes, function(attr) {
+				return ignore.indexOf(attr.name) === -1;
+			}).map(function(attr) {
+				return [attr.name, attr.value];
+			}).sort(function(a, b) {
+				return a[0] < b[0] ? -1 : a[0] > b[0] ? 1 : 0;
+			});
+			var children = [];
+			Array.prototype.forEach.call(node.childNodes, function(child) {
+				var c = normalize(child);
+				if (c !== null) {
+					children.push(c);
+				}
+			});
+			return [node.localName, attrs, children];
+		}
+		return JSON.stringify(normalize(document.documentElement));
+	})(%s)`
+
+	// resizeElementJS is a javascript snippet that sets the width and height
+	// of the element, returning a promise resolving once a ResizeObserver
+	// created afterwards has been notified, which happens after the observers
+	// created by the page, or after two animation frames if it isn't.
+	resizeElementJS = `(function(el, width, height) {
+		el.style.width = width + 'px';
+		el.style.height = height + 'px';
+		return new Promise(function(resolve) {
+			var observer = new ResizeObserver(function() {
+				observer.disconnect();
+				setTimeout(function() { resolve(true); });
+			});
+			observer.observe(el);
+			requestAnimationFrame(function() {
+				requestAnimationFrame(function() {
+					observer.disconnect();
+					resolve(true);
+				});
+			});
+		});
+	})(%s, %d, %d)`
+
+	// consoleTableJS is a javascript snippet that returns the rows of the
+	// given array or object as console.table would display them, wrapping the
+	// values which aren't objects, or null if there are no rows to display.
+	consoleTableJS = `(function(data) {
+		if (data === null || typeof data !== 'object') {
+			return null;
+		}
+		return Object.keys(data).map(function(key) {
+			var row = data[key];
+			return row !== null && typeof row === 'object' ? row : {Values: row};
+		});
+	})(%s)`
+
+	// pageStateJS is a javascript snippet that returns the title and URL of
+	// the document, and whether an element matches the CSS selector, which
+	// always holds for an empty selector.
+	pageStateJS = `(function(sel) {
+		return {
+			title: document.title,
+			url: location.href,
+			found: sel === '' || document.querySelector(sel) !== null
+		};
+	})(%s)`
+
+	// resourceTimingsJS is a javascript snippet that returns the resource
+	// timing entries of the document.
+	resourceTimingsJS = `performance.getEntriesByType('resource').map(function(e) {
+		return {
+			name: e.name,
+			initiatorType: e.initiatorType,
+			startTime: e.startTime,
+			duration: e.duration,
+			transferSize: e.transferSize
+		};
+	})`
+
+	// focusableJS is a javascript snippet that returns the elements of the
+	// document which can receive the keyboard focus, leaving out the disabled,
+	// hidden and inert ones, and the ones with a negative tabindex.
+	focusableJS = `Array.prototype.filter.call(document.querySelectorAll(
+		'a[href], area[href], button, input, select, textarea, iframe, summary, ' +
+		'audio[controls], video[controls], [contenteditable], [tabindex]'
+	), function(el) {
+		if (el.tabIndex < 0 || el.matches(':disabled') || el.closest('[inert]')) {
+			return false;
+		}
+		if (el.localName === 'input' && el.type === 'hidden') {
+			return false;
+		}
+		if (el.localName === 'summary' && !(el.parentElement && el.parentElement.localName === 'details')) {
+			return false;
+		}
+		var style = window.getComputedStyle(el);
+		return el.getClientRects().length > 0 && style.visibility !== 'hidden';
+	})`
+
+	// focusedJS is a javascript snippet that returns an array holding the
+	// element with the keyboard focus, following the focus into same-origin
+	// iframes, or an empty array if the focus isn't on any element of the
+	// document.
+	focusedJS = `(function() {
+		function focused(doc) {
+			var el = doc.activeElement;
+			return el && el !== doc.body && el !== doc.documentElement ? el : null;
+		}
+		var el = focused(document);
+		if (!el) {
+			return [];
+		}
+		for (;;) {
+			var doc = null;
+			try {
+				doc = el.contentDocument;
+			} catch (e) {}
+			var inner = doc && focused(doc);
+			if (!inner) {
+				return [el];
+			}
+			el = inner;
+		}
+	})()`
+
+	// hoveredJS is a javascript snippet that returns an array holding the
+	// innermost element in the :hover state, which is the last one in document
+	// order, or an empty array if there's none.
+	hoveredJS = `(function() {
+		var hovered = document.querySelectorAll(':hover');
+		return hovered.length > 0 ? [hovered[hovered.length - 1]] : [];
+	})()`
+
+	// visibilityRatioJS is a javascript snippet that returns a promise
+	// resolving to the ratio of the area of the element which intersects with
+	// the viewport, as reported by a one-shot IntersectionObserver.
+	visibilityRatioJS = `(function(el) {
+		return new Promise(function(resolve) {
+			var observer = new IntersectionObserver(function(entries) {
+				observer.disconnect();
+				resolve(entries[entries.length - 1].intersectionRatio);
+			});
+			observer.observe(el);
+		});
+	})(%s)`
+
+	// viewportRatioJS is a javascript snippet that returns true or false
+	// depending on whether at least the specified ratio of the area of the
+	// element is within the layout viewport. Elements without an area are
+	// considered inside when their position is within the viewport.
+	viewportRatioJS = `(function(a, ratio) {
+		var r = a.getBoundingClientRect();
+		var vw = document.documentElement.clientWidth, vh = document.documentElement.clientHeight;
+		var w = Math.min(r.right, vw) - Math.max(r.left, 0);
+		var h = Math.min(r.bottom, vh) - Math.max(r.top, 0);
+		if (r.width * r.height === 0) {
+			return w >= 0 && h >= 0;
+		}
+		if (w <= 0 || h <= 0) {
+			return false;
+		}
+		return (w * h) / (r.width * r.height) >= ratio;
+	})(%s, %g)`
+
+	// nodeCountJS is a javascript snippet that returns the number of element
+	// nodes in the document.
+	nodeCountJS = `document.getElementsByTagName('*').length`
+
+	// subtreeNodeCountJS is a javascript snippet that returns the number of
+	// element nodes in the subtree rooted at the specified element, including
+	// the element itself.
+	subtreeNodeCountJS = `(function(a) {
+		return a.getElementsByTagName('*').length + 1;
+	})(%s)`
+
+	// clientHintsJS is a javascript snippet that overrides the effective
+	// connection type reported by navigator.connection, and the value of
+	// navigator.deviceMemory. A null value leaves the property unchanged.
+	clientHintsJS = `(function(ect, memory) {
+		if (ect !== null && window.NetworkInformation) {
+			Object.defineProperty(NetworkInformation.prototype, 'effectiveType', {
+				get: function() { return ect; },
+				configurable: true
+			});
+		}
+		if (memory !== null) {
+			Object.defineProperty(Navigator.prototype, 'deviceMemory', {
+				get: function() { return memory; },
+				configurable: true
+			});
+		}
+	})(%s, %s)`
+
+	// hardwareConcurrencyJS is a javascript snippet that overrides the value
+	// of navigator.hardwareConcurrency.
+	hardwareConcurrencyJS = `(function(n) {
+		Object.defineProperty(Navigator.prototype, 'hardwareConcurrency', {
+			get: function() { return n; },
+			configurable: true
+		});
+	})(%d)`
+
+	// orientationChangeJS is a javascript snippet that stores a promise in the
+	// specified window property, resolving to true once the screen
+	// orientation has changed to the specified type and angle, or to false if
+	// it hasn't after the specified number of milliseconds.
+	orientationChangeJS = `(function(key, type, angle, timeout) {
+		window[key] = new Promise(function(resolve) {
+			var done = false;
+			function finish(changed) {
+				if (!done) {
+					done = true;
+					delete window[key];
+					resolve(changed);
+				}
+			}
+			setTimeout(function() { finish(false); }, timeout);
+			(function check() {
+				if (done) {
+					return;
+				}
+				if (screen.orientation.type !== type || screen.orientation.angle !== angle) {
+					requestAnimationFrame(check);
+					return;
+				}
+				finish(true);
+			})();
+		});
+	})(%q, %q, %d, %d)`
+
+	// viewportMetricsJS is a javascript snippet that returns the width and
+	// height of the viewport, and the device pixel ratio.
+	viewportMetricsJS = `[window.innerWidth, window.innerHeight, window.devicePixelRatio]`
+
+	// frameCounterStartJS is a javascript snippet that starts counting the
+	// animation frames rendered by the page, storing the counter in the
+	// specified window property.
+	frameCounterStartJS = `(function(key) {
+		var counter = {frames: 0, start: performance.now(), stopped: false};
+		window[key] = counter;
+		requestAnimationFrame(function tick() {
+			if (counter.stopped) {
+				return;
+			}
+			counter.frames++;
+			requestAnimationFrame(tick);
+		});
+	})(%q)`
+
+	// frameCounterStopJS is a javascript snippet that stops the frame counter
+	// stored in the specified window property, returning the number of frames
+	// rendered and the elapsed time in milliseconds.
+	frameCounterStopJS = `(function(key) {
+		var counter = window[key];
+		delete window[key];
+		if (!counter) {
+			return [0, 0];
+		}
+		counter.stopped = true;
+		return [counter.frames, performance.now() - counter.start];
+	})(%q)`
+
+	// heapLimitJS is a javascript snippet that returns the maximum size of
+	// the Javascript heap, or zero when it's not available.
+	heapLimitJS = `(performance.memory && performance.memory.jsHeapSizeLimit) || 0`
+
+	// saveDataJS is a javascript snippet that overrides the value of
+	// navigator.connection.saveData.
+	saveDataJS = `(function(enabled) {
+		if (window.NetworkInformation) {
+			Object.defineProperty(NetworkInformation.prototype, 'saveData', {
+				get: function() { return enabled; },
+				configurable: true
+			});
+		}
+	})(%t)`
+
+	// onlineJS is a javascript snippet that overrides the value of
+	// navigator.onLine, dispatching the online or offline event when dispatch
+	// is set and the value changed. The online and offline events fired by
+	// the browser itself are stopped, so that listeners are notified exactly
+	// once. The patch itself is only installed once per window.
+	onlineJS = `(function(online, dispatch) {
+		var key = '__chromedpOnline';
+		if (!window[key]) {
+			var state = window[key] = {online: navigator.onLine};
+			Object.defineProperty(Navigator.prototype, 'onLine', {
+				get: function() { return state.online; },
+				configurable: true
+			});
+			['online', 'offline'].forEach(function(type) {
+				window.addEventListener(type, function(e) {
+					if (e.isTrusted) {
+						e.stopImmediatePropagation();
+					}
+				}, true);
+			});
+		}
+		var prev = window[key].online;
+		window[key].online = online;
+		if (dispatch && prev !== online) {
+			window.dispatchEvent(new Event(online ? 'online' : 'offline'));
+		}
+	})(%t, %t)`
+
+	// cookiesEnabledJS is a javascript snippet that overrides
+	// navigator.cookieEnabled, making document.cookie read as an empty string
+	// and ignore writes while cookies are disabled. The patch itself is only
+	// installed once per window.
+	cookiesEnabledJS = `(function(enabled) {
+		var key = '__chromedpCookiesEnabled';
+		if (!window[key]) {
+			var state = window[key] = {enabled: true};
+			var cookie = Object.getOwnPropertyDescriptor(Document.prototype, 'cookie');
+			Object.defineProperty(Navigator.prototype, 'cookieEnabled', {
+				get: function() { return state.enabled; },
+				configurable: true
+			});
+			Object.defineProperty(Document.prototype, 'cookie', {
+				get: function() { return state.enabled ? cookie.get.call(this) : ''; },
+				set: function(value) {
+					if (state.enabled) {
+						cookie.set.call(this, value);
+					}
+				},
+				configurable: true
+			});
+		}
+		window[key].enabled = enabled;
+	})(%t)`
+
+	// permissionStateJS is a javascript snippet that overrides the state of
+	// a permission reported by navigator.permissions.query, along with
+	// Notification.permission for the notifications permission. The patch
+	// itself is only installed once per window.
+	permissionStateJS = `(function(name, state) {
+		var key = '__chromedpPermissions';
+		if (!window[key]) {
+			var states = window[key] = {};
+			var query = Permissions.prototype.query;
+			Permissions.prototype.query = function(desc) {
+				if (desc && states.hasOwnProperty(desc.name)) {
+					var status = new EventTarget();
+					Object.setPrototypeOf(status, PermissionStatus.prototype);
+					Object.defineProperties(status, {
+						name: {value: desc.name},
+						state: {value: states[desc.name]},
+						onchange: {value: null, writable: true}
+					});
+					return Promise.resolve(status);
+				}
+				return query.apply(this, arguments);
+			};
+			if (window.Notification) {
+				var permission = Object.getOwnPropertyDescriptor(Notification, 'permission');
+				Object.defineProperty(Notification, 'permission', {
+					get: function() {
+						if (!states.hasOwnProperty('notifications')) {
+							return permission.get.call(this);
+						}
+						var s = states.notifications;
+						return s === 'prompt' ? 'default' : s;
+					},
+					configurable: true
+				});
+			}
+		}
+		window[key][name] = state;
+	})(%s, %s)`
+
+	// noTranslateJS is a javascript snippet that marks the document as not to
+	// be translated, via the translate attribute and the "notranslate" meta
+	// tag honored by Chrome's translate prompt. As it may run before the
+	// document's elements exist, it's applied again once the document is
+	// parsed.
+	noTranslateJS = `(function() {
+		function apply() {
+			var html = document.documentElement;
+			if (!html) {
+				return;
+			}
+			html.setAttribute('translate', 'no');
+			if (!document.querySelector('meta[name="google"][content="notranslate"]')) {
+				var meta = document.createElement('meta');
+				meta.name = 'google';
+				meta.content = 'notranslate';
+				(document.head || html).appendChild(meta);
+			}
+		}
+		apply();
+		document.addEventListener('DOMContentLoaded', apply);
+	})()`
+
+	// smoothScrollJS is a javascript snippet that adds a constructed
+	// stylesheet disabling smooth scrolling to the document, which doesn't
+	// need the document's elements to exist yet. The stylesheet is only added
+	// once per document.
+	smoothScrollJS = `(function() {
+		var key = '__chromedpSmoothScroll';
+		if (document[key]) {
+			return;
+		}
+		var sheet = document[key] = new CSSStyleSheet();
+		sheet.replaceSync('html, html * { scroll-behavior: auto !important; }');
+		document.adoptedStyleSheets = document.adoptedStyleSheets.concat([sheet]);
+	})()`
+
+	// matchMediaJS is a javascript snippet that overrides whether the
+	// specified media query matches, as reported by window.matchMedia. Queries
+	// are compared in their serialized form, so that formatting differences
+	// don't matter. The patch itself is only installed once per window.
+	matchMediaJS = `(function(query, matches) {
+		var key = '__chromedpMatchMedia';
+		if (!window[key]) {
+			var overrides = window[key] = {};
+			var matchMedia = window.matchMedia;
+			window.matchMedia = function() {
+				var mql = matchMedia.apply(this, arguments);
+				if (Object.prototype.hasOwnProperty.call(overrides, mql.media)) {
+					Object.defineProperty(mql, 'matches', {
+						get: function() { return overrides[mql.media]; },
+						configurable: true
+					});
+				}
+				return mql;
+			};
+		}
+		window[key][window.matchMedia(query).media] = matches;
+	})(%s, %t)`
+
+	// toastJS is a javascript snippet that returns the text of the element.
+	// When requested, it instead returns a promise resolving to that text
+	// once the element is removed or not visible anymore, using a
+	// MutationObserver.
+	toastJS = `(function(a, waitDismissed) {
+		var text = a.innerText;
+		if (!waitDismissed) {
+			return text;
+		}
+		function dismissed() {
+			if (!a.isConnected) {
+				return true;
+			}
+			var style = window.getComputedStyle(a);
+			return a.getClientRects().length === 0 || style.visibility === 'hidden';
+		}
+		return new Promise(function(resolve) {
+			var observer = new MutationObserver(check);
+			function check() {
+				if (dismissed()) {
+					observer.disconnect();
+					resolve(text);
+				}
+			}
+			observer.observe(document, {childList: true, subtree: true, attributes: true, characterData: true});
+			check();
+		});
+	})(%s, %t)`
+
+	// anyVisibleJS is a javascript snippet that returns true or false
+	// depending on whether any element matching the specified CSS selector is
+	// visible in the document.
+	anyVisibleJS = `(function(sel) {
+		var els = document.querySelectorAll(sel);
+		for (var i = 0; i < els.length; i++) {
+			if (els[i].offsetWidth || els[i].offsetHeight || els[i].getClientRects().length) {
+				return true;
+			}
+		}
+		return false;
+	})(%s)`
+
+	// windowOpenHookJS is a javascript snippet that replaces window.open to
+	// report the resolved URL of each call to the specified binding, and to
+	// then apply the specified policy: "allow" opens the popup as usual,
+	// "same-tab" navigates the window itself instead, and "block" doesn't open
+	// anything. The hook itself is only installed once per window, and reads
+	// the policy set by the last run on each call.
+	windowOpenHookJS = `(function(binding, policy) {
+		var key = '__' + binding + 'Hooked';
+		if (window[key]) {
+			window[key].policy = policy;
+			return;
+		}
+		var state = window[key] = {policy: policy};
+		var open = window.open;
+		window.open = function(url) {
+			var resolved = 'about:blank';
+			if (url !== undefined && url !== '') {
+				try {
+					resolved = new URL(url, document.baseURI).href;
+				} catch (e) {
+					resolved = String(url);
+				}
+			}
+			if (typeof window[binding] === 'function') {
+				window[binding](resolved);
+			}
+			if (state.policy === 'same-tab') {
+				window.location.href = resolved;
+				return window;
+			}
+			if (state.policy === 'block') {
+				return null;
+			}
+			return open.apply(this, arguments);
+		};
+	})(%s, %s)`
+
+	// beaconHookJS is a javascript snippet that wraps navigator.sendBeacon,
+	// and listens for clicks on links with a ping attribute, reporting each
+	// beacon to the binding as JSON, with its payload decoded as text. The
+	// hook itself is only installed once per window.
+	beaconHookJS = `(function(binding) {
+		var key = '__' + binding + 'Hooked';
+		if (window[key]) {
+			return;
+		}
+		window[key] = true;
+		function report(type, url, payload) {
+			var resolved = String(url);
+			try {
+				resolved = new URL(url, document.baseURI).href;
+			} catch (e) {}
+			if (typeof window[binding] === 'function') {
+				window[binding](JSON.stringify({type: type, url: resolved, payload: payload}));
+			}
+		}
+		var sendBeacon = Navigator.prototype.sendBeacon;
+		Navigator.prototype.sendBeacon = function(url, data) {
+			if (data instanceof Blob) {
+				data.text().then(function(text) { report('beacon', url, text); });
+			} else if (data instanceof ArrayBuffer || ArrayBuffer.isView(data)) {
+				report('beacon', url, new TextDecoder().decode(data));
+			} else if (data instanceof FormData) {
+				report('beacon', url, new URLSearchParams(data).toString());
+			} else {
+				report('beacon', url, data === undefined || data === null ? '' : String(data));
+			}
+			return sendBeacon.apply(this, arguments);
+		};
+		document.addEventListener('click', function(e) {
+			var a = e.target instanceof Element && e.target.closest('a[ping][href], area[ping][href]');
+			if (!a) {
+				return;
+			}
+			a.getAttribute('ping').split(/\s+/).forEach(function(url) {
+				if (url !== '') {
+					report('ping', url, '');
+				}
+			});
+		}, true);
+	})(%q)`
+
+	// mutationsJS is a javascript snippet that observes the element's
+	// subtree, and calls the specified binding with the specified token once
+	// the specified number of mutation records have been observed.
+	mutationsJS = `(function(a, binding, token, count) {
+		var seen = 0;
+		var observer = new MutationObserver(function(records) {
+			seen += records.length;
+			if (seen >= count) {
+				observer.disconnect();
+				window[binding](token);
+			}
+		});
+		observer.observe(a, {childList: true, attributes: true, characterData: true, subtree: true});
+		return true;
+	})(%s, %q, %q, %d)`
+
+	// transitionStartJS is a javascript snippet that calls the binding with
+	// the token once a transition of the property starts on the element, or
+	// right away if one is already running. An empty property matches any
+	// transition.
+	transitionStartJS = `(function(a, binding, token, property) {
+		function matches(name) {
+			return property === '' || name === property;
+		}
+		var running = a.getAnimations().some(function(anim) {
+			return anim instanceof CSSTransition && anim.playState === 'running' && matches(anim.transitionProperty);
+		});
+		if (running) {
+			window[binding](token);
+			return true;
+		}
+		a.addEventListener('transitionstart', function listener(e) {
+			if (e.target === a && matches(e.propertyName)) {
+				a.removeEventListener('transitionstart', listener);
+				window[binding](token);
+			}
+		});
+		return true;
+	})(%s, %q, %q, %q)`
+
+	// axeRunJS is a javascript snippet that returns a promise resolving to the
+	// violations and incomplete results of running axe-core on the element,
+	// with the specified options.
+	axeRunJS = `(function(a, options) {
+		return axe.run(a, options).then(function(r) {
+			return {violations: r.violations, incomplete: r.incomplete};
+		});
+	})(%s, %s)`
+
+	// canvasDataJS is a javascript snippet that returns the content of the
+	// canvas element as a data URL of the specified format, or the reason why
+	// it could not be exported, such as the canvas being tainted by
+	// cross-origin data.
+	canvasDataJS = `(function(a, format) {
+		if (!(a instanceof HTMLCanvasElement)) {
+			return {error: 'not a canvas element'};
+		}
+		try {
+			return {url: a.toDataURL(format)};
+		} catch (e) {
+			return {error: e.message};
+		}
+	})(%s, %q)`
+
+	// cursorJS is a javascript snippet that renders an arrow cursor at the
+	// position of the last mouse event received by the window, restoring it
+	// from the session storage after navigating. The cursor is rendered within
+	// a closed shadow tree, on top of the document, and ignores pointer
+	// events. It's only installed once per window.
+	cursorJS = `(function() {
+		var key = '__chromedpCursor';
+		if (window[key]) {
+			return;
+		}
+		window[key] = true;
+		var svg = '<svg xmlns="http://www.w3.org/2000/svg" width="13" height="19">' +
+			'<path d="M1 1 L1 16 L5 12.5 L8 18 L10 17 L7.5 11.5 L12 11.5 Z" fill="black" stroke="white"/></svg>';
+		var cursor = document.createElement('chromedp-cursor');
+		cursor.style.cssText = 'position: fixed; top: 0; left: 0; width: 0; height: 0; z-index: 2147483647; pointer-events: none; display: none';
+		var arrow = document.createElement('div');
+		arrow.style.cssText = 'width: 13px; height: 19px; margin: -1px 0 0 -1px; background: url("data:image/svg+xml,' + encodeURIComponent(svg) + '")';
+		cursor.attachShadow({mode: 'closed'}).appendChild(arrow);
+		function move(x, y) {
+			cursor.style.transform = 'translate(' + x + 'px, ' + y + 'px)';
+			cursor.style.display = 'block';
+		}
+		function track(e) {
+			move(e.clientX, e.clientY);
+			try {
+				sessionStorage.setItem(key, e.clientX + ',' + e.clientY);
+			} catch (err) {}
+		}
+		['mousemove', 'mousedown', 'mouseup'].forEach(function(typ) {
+			window.addEventListener(typ, track, true);
+		});
+		function attach() {
+			document.documentElement.appendChild(cursor);
+			try {
+				var pos = sessionStorage.getItem(key);
+				if (pos) {
+					move.apply(null, pos.split(','));
+				}
+			} catch (err) {}
+		}
+		if (document.documentElement) {
+			attach();
+		} else {
+			document.addEventListener('DOMContentLoaded', attach);
+		}
+	})()`
+
+	// uniqueSelectorJS is a javascript snippet that returns the shortest CSS
+	// selector matching only the element, within its document or shadow
+	// root. The selector is built from the element up to its closest
+	// ancestor with a unique ID, or to the root, using the child combinator,
+	// and positions among siblings when the tag names are ambiguous.
+	uniqueSelectorJS = `(function(el) {
+		var root = el.getRootNode();
+		function matchesOnly(sel) {
+			var els = root.querySelectorAll(sel);
+			return els.length === 1 && els[0] === el;
+		}
+		function byID(e) {
+			if (!e.id) {
+				return null;
+			}
+			var sel = '#' + CSS.escape(e.id);
+			return root.querySelectorAll(sel).length === 1 ? sel : null;
+		}
+		var path = [];
+		for (var e = el; e && e.nodeType === Node.ELEMENT_NODE; e = e.parentNode) {
+			var id = byID(e);
+			if (id) {
+				path.unshift(id);
+				break;
+			}
+			var sel = CSS.escape(e.localName);
+			var parent = e.parentNode;
+			if (parent && parent.nodeType !== Node.DOCUMENT_NODE) {
+				var same = Array.prototype.filter.call(parent.children, function(c) {
+					return c.localName === e.localName;
+				});
+				if (same.length > 1) {
+					sel += ':nth-child(' + (Array.prototype.indexOf.call(parent.children, e) + 1) + ')';
+				}
+			}
+			path.unshift(sel);
+			if (matchesOnly(path.join(' > '))) {
+				break;
+			}
+		}
+		return path.join(' > ');
+	})(%s)`
+
+	// scrollContainerJS is a javascript snippet that scrolls the container
+	// towards the element, or by most of its height when the element is null
+	// or outside of it. It returns "visible" when the element is visible
+	// within the container, "end" when the container can't be scrolled any
+	// further, or "scrolled".
+	scrollContainerJS = `(function(container, el) {
+		var box = container.getBoundingClientRect();
+		var top = container.scrollTop, left = container.scrollLeft;
+		if (el && container.contains(el)) {
+			var r = el.getBoundingClientRect();
+			var x = r.left + r.width / 2, y = r.top + r.height / 2;
+			if (r.width > 0 && r.height > 0 &&
+					x >= box.left && x <= box.right && y >= box.top && y <= box.bottom &&
+					getComputedStyle(el).visibility !== 'hidden') {
+				return 'visible';
+			}
+			container.scrollTop += y - (box.top + box.height / 2);
+			container.scrollLeft += x - (box.left + box.width / 2);
+		} else {
+			container.scrollTop += Math.max(1, container.clientHeight * 0.8);
+		}
+		return container.scrollTop === top && container.scrollLeft === left ? 'end' : 'scrolled';
+	})(%s, %s)`
+
+	// stackingOrderJS is a javascript snippet that returns the indexes of
+	// the specified elements, sorted from the one painted on top to the one
+	// painted at the bottom. The order is computed from the stacking contexts
+	// of the elements, and their layers within them, following the painting
+	// order of CSS; elements in the same layer are painted in tree order.
+	stackingOrderJS = `(function(els) {
+		function style(el) {
+			return getComputedStyle(el);
+		}
+		// zIndex returns the z-index of the element, or null when it
+		// doesn't apply or is auto.
+		function zIndex(el) {
+			var s = style(el);
+			if (s.zIndex === 'auto') {
+				return null;
+			}
+			var parent = el.parentElement;
+			var item = parent && /flex|grid/.test(style(parent).display);
+			if (s.position === 'static' && !item) {
+				return null;
+			}
+			return parseInt(s.zIndex, 10);
+		}
+		function isContext(el) {
+			if (el === document.documentElement || zIndex(el) !== null) {
+				return true;
+			}
+			var s = style(el);
+			return s.position === 'fixed' || s.position === 'sticky' ||
+				parseFloat(s.opacity) < 1 ||
+				s.transform !== 'none' || s.filter !== 'none' ||
+				s.perspective !== 'none' || s.clipPath !== 'none' ||
+				s.isolation === 'isolate' || s.mixBlendMode !== 'normal' ||
+				/transform|opacity|filter|perspective|z-index/.test(s.willChange) ||
+				/paint|layout|strict|content/.test(s.contain);
+		}
+		// chain returns the stacking contexts the element is painted
+		// within, from the root, followed by the element itself.
+		function chain(el) {
+			var c = [el];
+			for (var p = el.parentElement; p; p = p.parentElement) {
+				if (isContext(p)) {
+					c.unshift(p);
+				}
+			}
+			return c;
+		}
+		// layer returns the layer the element is painted in within its
+		// stacking context, and its z-index within the layer.
+		function layer(el) {
+			var z = zIndex(el);
+			if (z !== null && z !== 0) {
+				return z < 0 ? [0, z] : [3, z];
+			}
+			if (z === 0 || style(el).position !== 'static' || isContext(el)) {
+				return [2, 0];
+			}
+			return [1, 0];
+		}
+		// compare returns a negative number when a is painted below b.
+		function compare(a, b) {
+			if (a === b) {
+				return 0;
+			}
+			var ca = chain(a), cb = chain(b);
+			var i = 0;
+			while (i < ca.length && i < cb.length && ca[i] === cb[i]) {
+				i++;
+			}
+			// a stacking context is painted below its content
+			if (i === ca.length) {
+				return -1;
+			}
+			if (i === cb.length) {
+				return 1;
+			}
+			var x = ca[i], y = cb[i];
+			var lx = layer(x), ly = layer(y);
+			if (lx[0] !== ly[0]) {
+				return lx[0] - ly[0];
+			}
+			if (lx[1] !== ly[1]) {
+				return lx[1] - ly[1];
+			}
+			return x.compareDocumentPosition(y) & Node.DOCUMENT_POSITION_FOLLOWING ? -1 : 1;
+		}
+		var order = els.map(function(el, i) { return i; });
+		order.sort(function(i, j) {
+			return compare(els[j], els[i]);
+		});
+		return order;
+	})(%s)`
+
+	// mediaStateJS is a javascript snippet that returns whether the media
+	// element is in the state, or has played at least the time in seconds.
+	mediaStateJS = `(function(el, state, time) {
+		if (!(el instanceof HTMLMediaElement)) {
+			throw new Error('not a media element');
+		}
+		switch (state) {
+		case 'playing':
+			return !el.paused && !el.ended && el.readyState > HTMLMediaElement.HAVE_CURRENT_DATA;
+		case 'paused':
+			return el.paused && !el.ended;
+		case 'ended':
+			return el.ended;
+		}
+		return el.currentTime >= time;
+	})(%s, %q, %v)`
+
+	// mediaJS is a javascript snippet that runs the action, one of play,
+	// pause or mute, on all the audio and video elements of the document,
+	// returning the errors of the elements which failed to play.
+	mediaJS = `(function(action) {
+		var els = Array.prototype.slice.call(document.querySelectorAll('audio, video'));
+		return Promise.all(els.map(function(el) {
+			switch (action) {
+			case 'play':
+				return el.play().then(function() {
+					return null;
+				}, function(err) {
+					return (el.currentSrc || el.localName) + ': ' + err.message;
+				});
+			case 'pause':
+				el.pause();
+				break;
+			case 'mute':
+				el.muted = true;
+				break;
+			}
+			return null;
+		})).then(function(errs) {
+			return errs.filter(function(err) { return err !== null; });
+		});
+	})(%q)`
+
+	// fillFormJS is a javascript snippet that sets the values of the fields of
+	// the specified form, matching each field by name, id, or label text. The
+	// input and change events are dispatched on each modified field. Returns
+	// the list of problems encountered, in which case no field is modified.
+	fillFormJS = `(function(form, values) {
+		function find(key) {
+			var i, elems = form.elements;
+			for (i = 0; i < elems.length; i++) {
+				if (elems[i].name === key) {
+					return elems[i];
+				}
+			}
+			for (i = 0; i < elems.length; i++) {
+				if (elems[i].id === key) {
+					return elems[i];
+				}
+			}
+			var labels = form.querySelectorAll('label');
+			for (i = 0; i < labels.length; i++) {
+				if (labels[i].textContent.trim() === key && labels[i].control) {
+					return labels[i].control;
+				}
+			}
+			return null;
+		}
+		function radio(el, value) {
+			var elems = form.elements;
+			for (var i = 0; i < elems.length; i++) {
+				if (elems[i].type === 'radio' && elems[i].name === el.name && elems[i].value === value) {
+					return elems[i];
+				}
+			}
+			return null;
+		}
+		function hasOption(el, value) {
+			for (var i = 0; i < el.options.length; i++) {
+				if (el.options[i].value === value) {
+					return true;
+				}
+			}
+			return false;
+		}
+		var problems = [], fields = [];
+		for (var key in values) {
+			var el = find(key), value = values[key];
+			if (el === null) {
+				problems.push('no field matching ' + JSON.stringify(key));
+				continue;
+			}
+			if (el.type === 'radio') {
+				el = radio(el, value);
+				if (el === null) {
+					problems.push('no radio button for ' + JSON.stringify(key) + ' with value ' + JSON.stringify(value));
+					continue;
+				}
+			} else if (el.tagName === 'SELECT' && !hasOption(el, value)) {
+				problems.push('no option for ' + JSON.stringify(key) + ' with value ' + JSON.stringify(value));
+				continue;
+			}
+			fields.push([el, value]);
+		}
+		if (problems.length > 0) {
+			return problems;
+		}
+		for (var i = 0; i < fields.length; i++) {
+			var el = fields[i][0], value = fields[i][1];
+			switch (el.type) {
+			case 'radio':
+				el.checked = true;
+				break;
+			case 'checkbox':
+				el.checked = ['', '0', 'false', 'off'].indexOf(value.toLowerCase()) === -1;
+				break;
+			default:
+				el.value = value;
+			}
+			el.dispatchEvent(new Event('input', {bubbles: true}));
+			el.dispatchEvent(new Event('change', {bubbles: true}));
+		}
+		return problems;
+	})(%s, %s)`
 )
 
 // snippet builds a Javascript expression snippet.
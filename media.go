@@ -0,0 +1,113 @@
+package chromedp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+)
+
+// PlayAllMedia is an action that starts playing all the audio and video
+// elements of the current document.
+//
+// The elements are played via their play method, as if the user clicked on
+// their play button, so the page's players are notified via the usual media
+// events and can update their controls. An error listing the elements which
+// failed to play, such as because they have no playable source, is returned.
+func PlayAllMedia() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		return allMedia(ctx, "play")
+	})
+}
+
+// PauseAllMedia is an action that pauses all the audio and video elements of
+// the current document, such as to keep autoplaying media from altering
+// screenshots and timings.
+//
+// As with PlayAllMedia, the pause events are dispatched as usual.
+func PauseAllMedia() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		return allMedia(ctx, "pause")
+	})
+}
+
+// MuteAll is an action that mutes all the audio and video elements of the
+// current document. The volumechange events are dispatched as usual.
+//
+// See the Headless allocator option, which mutes the audio of the whole
+// browser.
+func MuteAll() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		return allMedia(ctx, "mute")
+	})
+}
+
+// NodeMediaState is an element query option to wait until all queried audio
+// or video element nodes are in the state, one of "playing", "paused" or
+// "ended".
+//
+// A media element is only considered playing once it has data to play, so not
+// while it's buffering; a media element which ended isn't considered paused.
+// The query returns an error for any other state.
+func NodeMediaState(state string) QueryOption {
+	switch state {
+	case "playing", "paused", "ended":
+	default:
+		return func(s *Selector) {
+			s.err = fmt.Errorf("invalid media state %q", state)
+		}
+	}
+	return nodeMedia(state, 0)
+}
+
+// NodeMediaTime is an element query option to wait until all queried audio or
+// video element nodes have a current playback position of at least seconds.
+func NodeMediaTime(seconds float64) QueryOption {
+	return nodeMedia("", seconds)
+}
+
+// nodeMedia returns an element query option to wait until the media elements
+// are in the state, or at the time when the state is empty.
+func nodeMedia(state string, seconds float64) QueryOption {
+	return func(s *Selector) {
+		WaitFunc(s.waitReady(func(ctx context.Context, n *cdp.Node) error {
+			var res bool
+			if err := EvaluateAsDevTools(snippet(mediaStateJS, cashX(true), s, n, state, seconds), &res).Do(ctx); err != nil {
+				return err
+			}
+			if !res {
+				return ErrMediaNotReady
+			}
+			return nil
+		}))(s)
+	}
+}
+
+// WaitMediaState is an element query action that waits until the audio or
+// video element nodes matching the selector are in the state. See
+// NodeMediaState for the states.
+//
+// Useful to test the controls of a custom media player.
+func WaitMediaState(sel interface{}, state string, opts ...QueryOption) QueryAction {
+	return Query(sel, append(opts, NodeMediaState(state))...)
+}
+
+// WaitMediaTime is an element query action that waits until the audio or
+// video element nodes matching the selector have played up to seconds.
+func WaitMediaTime(sel interface{}, seconds float64, opts ...QueryOption) QueryAction {
+	return Query(sel, append(opts, NodeMediaTime(seconds))...)
+}
+
+// allMedia runs the media action on all the audio and video elements of the
+// current document.
+func allMedia(ctx context.Context, action string) error {
+	var errs []string
+	if err := Evaluate(fmt.Sprintf(mediaJS, action), &errs, evalAwaitPromise, evalUserGesture).Do(ctx); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not %s media: %s", action, strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package chromedp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// silentWAV returns a data URL of a WAV file with a second of silence.
+func silentWAV() string {
+	const rate = 8000
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+rate))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, []interface{}{
+		uint32(16), uint16(1), uint16(1), uint32(rate), uint32(rate), uint16(1), uint16(8),
+	})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(rate))
+	buf.Write(bytes.Repeat([]byte{128}, rate))
+	return "data:audio/wav;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestPlayAllMedia(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(fmt.Sprintf(`
+<audio src="%[1]s" loop></audio>
+<video src="%[1]s" loop></video>
+<script>
+	window.events = [];
+	document.querySelectorAll('audio, video').forEach(function(el) {
+		['play', 'pause', 'volumechange'].forEach(function(type) {
+			el.addEventListener(type, function() {
+				window.events.push(el.localName + ' ' + type);
+			});
+		});
+	});
+</script>
+	`, silentWAV())))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const state = `Array.prototype.map.call(document.querySelectorAll('audio, video'), function(el) {
+		return [el.paused, el.muted];
+	})`
+	var playing, muted, paused [][]bool
+	var events []string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		PlayAllMedia(),
+		Evaluate(state, &playing),
+		MuteAll(),
+		Evaluate(state, &muted),
+		PauseAllMedia(),
+		Evaluate(state, &paused),
+		// the media events are dispatched asynchronously
+		Evaluate(`new Promise(function(resolve) {
+			setTimeout(function() { resolve(window.events); }, 50);
+		})`, &events, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]bool{{false, false}, {false, false}}; !reflect.DeepEqual(playing, want) {
+		t.Errorf("expected the media to be playing, got: %v", playing)
+	}
+	if want := [][]bool{{false, true}, {false, true}}; !reflect.DeepEqual(muted, want) {
+		t.Errorf("expected the media to be muted, got: %v", muted)
+	}
+	if want := [][]bool{{true, true}, {true, true}}; !reflect.DeepEqual(paused, want) {
+		t.Errorf("expected the media to be paused, got: %v", paused)
+	}
+	want := []string{
+		"audio play", "video play",
+		"audio volumechange", "video volumechange",
+		"audio pause", "video pause",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected events %v, got: %v", want, events)
+	}
+
+	if err := Run(ctx,
+		Evaluate(`document.body.appendChild(document.createElement('audio'))`, &[]byte{}),
+		PlayAllMedia(),
+	); err == nil {
+		t.Error("expected an error for media without a source")
+	}
+}
+
+func TestWaitMediaState(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(fmt.Sprintf(`<audio id="audio" src="%s"></audio>`, silentWAV())))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var paused bool
+	var played float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitMediaState(`#audio`, "paused", ByID),
+		PlayAllMedia(),
+		WaitMediaState(`#audio`, "playing", ByID),
+		WaitMediaTime(`#audio`, 0.2, ByID),
+		Evaluate(`document.getElementById('audio').currentTime`, &played),
+		WaitMediaState(`#audio`, "ended", ByID),
+		Evaluate(`document.getElementById('audio').paused`, &paused),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if played < 0.2 {
+		t.Errorf("expected the audio to have played at least 0.2s, got: %fs", played)
+	}
+	if !paused {
+		t.Error("expected the audio to be paused once ended")
+	}
+
+	if err := Run(ctx, WaitMediaState(`#audio`, "stopped", ByID)); err == nil {
+		t.Error("expected an error for an invalid media state")
+	}
+}
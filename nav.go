@@ -2,28 +2,420 @@ package chromedp
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 )
 
 // NavigateAction are actions that manipulate the navigation of the browser.
 type NavigateAction Action
 
 // Navigate is an action that navigates the current frame.
-func Navigate(urlstr string) NavigateAction {
+func Navigate(urlstr string, opts ...NavigateOption) NavigateAction {
+	var o navigateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return ActionFunc(func(ctx context.Context) error {
-		expect, release := expectLifecycleLoaded(ctx)
+		redirectErr := make(chan error, 1)
+		if o.maxRedirects > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+			if err := network.Enable().Do(ctx); err != nil {
+				return err
+			}
+			frameID := navigatedFrameID(ctx)
+			var chain []string
+			ListenTarget(ctx, func(ev interface{}) {
+				e, ok := ev.(*network.EventRequestWillBeSent)
+				if !ok || e.FrameID != frameID || e.RequestID != network.RequestID(e.LoaderID) {
+					return
+				}
+				if e.RedirectResponse == nil {
+					chain = []string{e.Request.URL}
+					return
+				}
+				chain = append(chain, e.Request.URL)
+				if len(chain)-1 > o.maxRedirects {
+					select {
+					case redirectErr <- fmt.Errorf("navigation to %q exceeded %d redirects: %s", urlstr, o.maxRedirects, strings.Join(chain, " -> ")):
+					default:
+					}
+					cancel()
+				}
+			})
+		}
+
+		local := isLocalURL(urlstr)
+		lctx := ctx
+		if local {
+			var cancel context.CancelFunc
+			lctx, cancel = context.WithCancel(ctx)
+			defer cancel()
+		}
+		expect, release := expectLifecycleLoaded(lctx)
 		defer release()
-		_, _, _, err = page.Navigate(urlstr).Do(ctx)
-		if err != nil {
+		_, _, _, err := page.Navigate(urlstr).WithReferrer(o.referrer).Do(ctx)
+		if err == nil {
+			if local {
+				err = waitLocalLoaded(ctx, expect)
+			} else {
+				err = expect()
+			}
+		}
+		select {
+		case err := <-redirectErr:
+			return err
+		default:
 			return err
 		}
-		return expect()
 	})
 }
 
+// isLocalURL returns whether urlstr is a data or about URL, whose documents
+// aren't fetched over the network.
+func isLocalURL(urlstr string) bool {
+	urlstr = strings.ToLower(urlstr)
+	return strings.HasPrefix(urlstr, "data:") || strings.HasPrefix(urlstr, "about:")
+}
+
+// waitLocalLoaded waits until the document navigated to via a data or about
+// URL has loaded. The load lifecycle event isn't always sent for those, so the
+// document is also considered loaded once its ready state is complete.
+//
+// The context expect was created with must be cancelled after this returns.
+func waitLocalLoaded(ctx context.Context, expect expectFunc) error {
+	loaded := make(chan error, 1)
+	go func() {
+		loaded <- expect()
+	}()
+	return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+		select {
+		case err := <-loaded:
+			return true, err
+		default:
+		}
+		var state string
+		if err := Evaluate(`document.readyState`, &state).Do(ctx); err != nil {
+			// The execution context may be replaced while
+			// navigating, so try again.
+			return false, nil
+		}
+		return state == "complete", nil
+	})
+}
+
+type navigateOptions struct {
+	maxRedirects int
+	referrer     string
+}
+
+// NavigateOption is a Navigate action option.
+type NavigateOption = func(*navigateOptions)
+
+// NavigateMaxRedirects is a Navigate action option to fail the navigation as
+// soon as the top-level navigation is redirected more than n times, returning
+// an error listing the redirect chain. Useful to surface redirect loops, which
+// would otherwise leave the navigation waiting for the page to load.
+//
+// Note: the Network domain is enabled to follow the redirects.
+func NavigateMaxRedirects(n int) NavigateOption {
+	return func(o *navigateOptions) {
+		o.maxRedirects = n
+	}
+}
+
+// NavigateReferrer is a Navigate action option to set the referrer of the
+// navigation, as if it was started from a link in the referrer document.
+func NavigateReferrer(referrer string) NavigateOption {
+	return func(o *navigateOptions) {
+		o.referrer = referrer
+	}
+}
+
+// NavigateChain is an action that navigates the current frame to each of the
+// URLs in turn, setting the referrer of each navigation to the URL of the
+// previously loaded document, as trimmed by the referrer policy, such as
+// "origin" or "no-referrer-when-downgrade". An empty policy defaults to
+// "strict-origin-when-cross-origin". The first navigation has no referrer.
+//
+// Useful to test how a referrer propagates through a funnel of pages. Note
+// that the browser may further trim the referrers, according to its own
+// default policy.
+func NavigateChain(urls []string, referrerPolicy string) NavigateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		if _, err := policyReferrer(referrerPolicy, "", ""); err != nil {
+			return err
+		}
+		var prev string
+		for _, urlstr := range urls {
+			var referrer string
+			if prev != "" {
+				var err error
+				if referrer, err = policyReferrer(referrerPolicy, prev, urlstr); err != nil {
+					return err
+				}
+			}
+			if err := Navigate(urlstr, NavigateReferrer(referrer)).Do(ctx); err != nil {
+				return err
+			}
+			if err := Location(&prev).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// policyReferrer returns the referrer of a request from the document at from
+// to the URL to, as determined by the referrer policy.
+func policyReferrer(policy, from, to string) (string, error) {
+	// validate the policy regardless of the URLs
+	switch policy {
+	case "", "no-referrer", "no-referrer-when-downgrade", "origin",
+		"origin-when-cross-origin", "same-origin", "strict-origin",
+		"strict-origin-when-cross-origin", "unsafe-url":
+	default:
+		return "", fmt.Errorf("invalid referrer policy %q", policy)
+	}
+
+	f, err := url.Parse(from)
+	if err != nil || (f.Scheme != "http" && f.Scheme != "https") {
+		// documents not fetched over HTTP have no referrer
+		return "", nil
+	}
+	t, err := url.Parse(to)
+	if err != nil {
+		return "", err
+	}
+	full := *f
+	full.User, full.Fragment = nil, ""
+	origin := url.URL{Scheme: f.Scheme, Host: f.Host, Path: "/"}
+	cross := f.Scheme != t.Scheme || f.Host != t.Host
+	downgrade := f.Scheme == "https" && t.Scheme != "https"
+
+	switch {
+	case policy == "no-referrer",
+		policy == "same-origin" && cross,
+		(policy == "no-referrer-when-downgrade" || policy == "strict-origin" || policy == "strict-origin-when-cross-origin" || policy == "") && downgrade:
+		return "", nil
+	case policy == "origin", policy == "strict-origin",
+		(policy == "origin-when-cross-origin" || policy == "strict-origin-when-cross-origin" || policy == "") && cross:
+		return origin.String(), nil
+	}
+	return full.String(), nil
+}
+
+// NavigateWithRetry is an action that navigates the current frame, retrying
+// up to attempts times in total when the navigation fails due to a transient
+// network error, such as net::ERR_CONNECTION_RESET or net::ERR_TIMED_OUT. The
+// retries are delayed by backoff, doubled after each attempt.
+//
+// Unlike Navigate, which loads the browser's error page, an error is returned
+// when the navigation fails; errors which aren't transient, such as
+// net::ERR_NAME_NOT_RESOLVED, are returned right away. Useful for environments
+// which drop connections now and then.
+func NavigateWithRetry(urlstr string, attempts int, backoff time.Duration) NavigateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		for i := 0; ; i++ {
+			expect, release := expectLifecycleLoaded(ctx)
+			_, _, errorText, err := page.Navigate(urlstr).Do(ctx)
+			if err == nil && errorText == "" {
+				err = expect()
+			}
+			release()
+			if err != nil {
+				return err
+			}
+			if errorText == "" {
+				return nil
+			}
+			if !transientNetError(errorText) || i+1 >= attempts {
+				return fmt.Errorf("navigation to %q failed: %s", urlstr, errorText)
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff << uint(i)):
+			}
+		}
+	})
+}
+
+// NavigateAndCaptureBody is an action that navigates the current frame, and
+// retrieves the raw body of the response of the navigation, as it was served
+// before any script ran, storing it in body. Useful to compare the document
+// rendered by a server with the one scripts eventually build.
+//
+// When the navigation is redirected, the body of the final response is
+// retrieved.
+//
+// Note: the Network domain is enabled.
+func NavigateAndCaptureBody(urlstr string, body *[]byte) NavigateAction {
+	if body == nil {
+		panic("body cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var mu sync.Mutex
+		var requestID network.RequestID
+		frameID := navigatedFrameID(ctx)
+		ListenTarget(lctx, func(ev interface{}) {
+			// Navigation requests share their ID with the loader.
+			if e, ok := ev.(*network.EventRequestWillBeSent); ok && e.FrameID == frameID && e.RequestID == network.RequestID(e.LoaderID) {
+				mu.Lock()
+				requestID = e.RequestID
+				mu.Unlock()
+			}
+		})
+		if err := Navigate(urlstr).Do(ctx); err != nil {
+			return err
+		}
+		cancel()
+
+		mu.Lock()
+		id := requestID
+		mu.Unlock()
+		if id == "" {
+			return fmt.Errorf("no request was made for the navigation to %q", urlstr)
+		}
+		var err error
+		*body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	})
+}
+
+// transientNetError returns whether the network error reported by the browser,
+// such as "net::ERR_CONNECTION_RESET", is likely to go away when retrying.
+func transientNetError(errorText string) bool {
+	switch strings.TrimPrefix(errorText, "net::") {
+	case "ERR_CONNECTION_RESET", "ERR_CONNECTION_CLOSED",
+		"ERR_CONNECTION_ABORTED", "ERR_CONNECTION_REFUSED",
+		"ERR_CONNECTION_FAILED", "ERR_CONNECTION_TIMED_OUT",
+		"ERR_TIMED_OUT", "ERR_EMPTY_RESPONSE", "ERR_NETWORK_CHANGED",
+		"ERR_ADDRESS_UNREACHABLE", "ERR_SOCKET_NOT_CONNECTED",
+		"ERR_HTTP2_PING_FAILED", "ERR_HTTP2_SERVER_REFUSED_STREAM",
+		"ERR_QUIC_PROTOCOL_ERROR":
+		return true
+	}
+	return false
+}
+
+// RedirectChain is an action that retrieves the URLs the last top-level
+// navigation went through, starting with the requested URL and ending with
+// the URL of the loaded document. A navigation without redirects has a chain
+// of a single URL.
+//
+// Note: navigations are only recorded while the Network domain is enabled, so
+// network.Enable must be run before navigating.
+func RedirectChain(urls *[]string) NavigateAction {
+	if urls == nil {
+		panic("urls cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		chain := t.lastRedirectChain()
+		if len(chain) == 0 {
+			return errors.New("no navigation recorded while the Network domain was enabled")
+		}
+		*urls = chain
+		return nil
+	})
+}
+
+// WaitPageStable is an action that waits until the current page is stable
+// enough for reliable screenshots: the first element node matching the
+// selector is visible, the document's fonts have loaded, the network has been
+// idle for 500ms, and all the running animations which eventually end have
+// finished.
+//
+// Note: the Network domain is enabled, as with WaitNetworkIdle.
+func WaitPageStable(sel interface{}, opts ...QueryOption) Action {
+	return Tasks{
+		WaitVisible(sel, opts...),
+		WaitNetworkIdle(0, 500*time.Millisecond),
+		Evaluate(pageStableJS, &[]byte{}, evalAwaitPromise),
+	}
+}
+
+// SetContent is an action that replaces the content of the current frame's
+// document with the HTML markup, and then waits until the document and its
+// resources have loaded.
+//
+// Useful for rendering HTML fragments without serving them. Relative resource
+// URLs are resolved against the document's current URL, unless the
+// SetContentBaseURL option is used.
+func SetContent(markup string, opts ...SetContentOption) NavigateAction {
+	var o setContentOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.baseURL != "" {
+		base := `<base href="` + html.EscapeString(o.baseURL) + `">`
+		// Anything before the doctype would trigger quirks mode.
+		if loc := doctypeRE.FindStringIndex(markup); loc != nil {
+			markup = markup[:loc[1]] + base + markup[loc[1]:]
+		} else {
+			markup = base + markup
+		}
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		frameID := navigatedFrameID(ctx)
+		if frameID == "" {
+			return ErrInvalidTarget
+		}
+		if err := page.SetDocumentContent(frameID, markup).Do(ctx); err != nil {
+			return err
+		}
+		var loaded bool
+		return Evaluate(documentLoadedJS, &loaded, evalAwaitPromise).Do(ctx)
+	})
+}
+
+// doctypeRE matches a leading doctype declaration.
+var doctypeRE = regexp.MustCompile(`(?i)^\s*<!doctype[^>]*>`)
+
+type setContentOptions struct {
+	baseURL string
+}
+
+// SetContentOption is a SetContent action option.
+type SetContentOption = func(*setContentOptions)
+
+// SetContentBaseURL is a SetContent action option to resolve the relative URLs
+// of the document against baseURL, by adding a <base> element to the markup.
+func SetContentBaseURL(baseURL string) SetContentOption {
+	return func(o *setContentOptions) {
+		o.baseURL = baseURL
+	}
+}
+
 // NavigationEntries is an action that retrieves the page's navigation history
 // entries.
 func NavigationEntries(currentIndex *int64, entries *[]*page.NavigationEntry) NavigateAction {
@@ -95,6 +487,145 @@ func NavigateForward() NavigateAction {
 	})
 }
 
+// HistoryLength is an action that retrieves the number of entries in the
+// session history of the current window, as reported by history.length.
+func HistoryLength(n *int64) Action {
+	if n == nil {
+		panic("n cannot be nil")
+	}
+	return EvaluateAsDevTools(`history.length`, n)
+}
+
+// HistoryState is an action that retrieves the state of the current session
+// history entry, as set by history.pushState or history.replaceState. The state
+// is null when none was set.
+func HistoryState(state *json.RawMessage) Action {
+	if state == nil {
+		panic("state cannot be nil")
+	}
+	return ActionFunc(func(ctx context.Context) error {
+		var buf []byte
+		if err := EvaluateAsDevTools(`history.state`, &buf).Do(ctx); err != nil {
+			return err
+		}
+		*state = json.RawMessage(buf)
+		return nil
+	})
+}
+
+// PushState is an action that adds an entry with the state and URL to the
+// session history of the current window, via history.pushState, and waits for
+// the resulting same-document navigation. The URL may be relative to the
+// current one; when empty, the current URL is kept.
+//
+// As with history.pushState, no popstate event is dispatched; use
+// DispatchPopState afterwards for client-side routers to react to the change.
+func PushState(urlstr string, state interface{}) NavigateAction {
+	return historyState("pushState", urlstr, state)
+}
+
+// ReplaceState is an action like PushState, but replacing the current entry of
+// the session history, via history.replaceState.
+func ReplaceState(urlstr string, state interface{}) NavigateAction {
+	return historyState("replaceState", urlstr, state)
+}
+
+// DispatchPopState is an action that dispatches a synthetic popstate event
+// with the current history.state on the current window, as when navigating
+// through the session history, for client-side routers to react to a change
+// made via PushState or ReplaceState.
+func DispatchPopState() Action {
+	return Evaluate(dispatchPopStateJS, &[]byte{})
+}
+
+// historyState calls the method of the History API, and waits for the resulting
+// same-document navigation.
+func historyState(method, urlstr string, state interface{}) NavigateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		stateJSON, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+
+		frameID := navigatedFrameID(ctx)
+		expect, release := expectEvent(ctx, func(ev interface{}) bool {
+			e, ok := ev.(*page.EventNavigatedWithinDocument)
+			return ok && e.FrameID == frameID
+		})
+		defer release()
+		if err := Evaluate(fmt.Sprintf(historyStateJS, method, stateJSON, urlstr), &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+		return expect()
+	})
+}
+
+// ScrollRestoration is an action that retrieves the scroll restoration mode of
+// the session history, either "auto" or "manual".
+func ScrollRestoration(mode *string) Action {
+	if mode == nil {
+		panic("mode cannot be nil")
+	}
+	return EvaluateAsDevTools(`history.scrollRestoration`, mode)
+}
+
+// SetScrollRestoration is an action that sets the scroll restoration mode of
+// the session history, either "auto" or "manual".
+func SetScrollRestoration(mode string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if mode != "auto" && mode != "manual" {
+			return fmt.Errorf("invalid scroll restoration mode %q", mode)
+		}
+		return EvaluateAsDevTools(fmt.Sprintf(`history.scrollRestoration = %q`, mode), &[]byte{}).Do(ctx)
+	})
+}
+
+// NavigateWithBFCache is an action that navigates the current frame to
+// urlstr, and then back to the current page, storing whether the page was
+// restored from the back/forward cache in restored, as reported by the
+// persisted property of the pageshow event.
+//
+// Useful for testing that a page behaves correctly when restored from the
+// cache, or that nothing prevents it from being cached.
+func NavigateWithBFCache(urlstr string, restored *bool) NavigateAction {
+	if restored == nil {
+		panic("restored cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := Evaluate(bfcacheHookJS, &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+		if err := Navigate(urlstr).Do(ctx); err != nil {
+			return err
+		}
+
+		cur, entries, err := page.GetNavigationHistory().Do(ctx)
+		if err != nil {
+			return err
+		}
+		if cur <= 0 || cur > int64(len(entries)-1) {
+			return errors.New("invalid navigation entry")
+		}
+
+		// A page restored from the cache isn't loaded again, so wait for
+		// the navigation to be committed instead.
+		frameID := navigatedFrameID(ctx)
+		expect, release := expectEvent(ctx, func(ev interface{}) bool {
+			e, ok := ev.(*page.EventFrameNavigated)
+			return ok && e.Frame.ID == frameID
+		})
+		defer release()
+		if err := page.NavigateToHistoryEntry(entries[cur-1].ID).Do(ctx); err != nil {
+			return err
+		}
+		if err := expect(); err != nil {
+			return err
+		}
+		return Evaluate(bfcacheRestoredJS, restored, evalAwaitPromise).Do(ctx)
+	})
+}
+
 // Reload is an action that reloads the current page.
 func Reload() NavigateAction {
 	return ActionFunc(func(ctx context.Context) error {
@@ -107,6 +638,119 @@ func Reload() NavigateAction {
 	})
 }
 
+// AutoHandleBeforeUnload is an action that makes the current target handle
+// every beforeunload dialog from then on, accepting it (leaving the page) or
+// dismissing it (staying on the page) depending on accept.
+//
+// Useful for pages that warn about unsaved changes, as the dialog otherwise
+// blocks any navigation until it is handled. Other dialogs are left alone.
+func AutoHandleBeforeUnload(accept bool) NavigateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		ListenTarget(ctx, func(ev interface{}) {
+			e, ok := ev.(*page.EventJavascriptDialogOpening)
+			if !ok || e.Type != page.DialogTypeBeforeunload {
+				return
+			}
+			go page.HandleJavaScriptDialog(accept).Do(ctx)
+		})
+		return nil
+	})
+}
+
+// WindowOpenPolicy is the policy applied to window.open calls by
+// HandleWindowOpen.
+type WindowOpenPolicy string
+
+// WindowOpenPolicy values.
+const (
+	// WindowOpenAllow opens the popup as usual.
+	WindowOpenAllow WindowOpenPolicy = "allow"
+
+	// WindowOpenSameTab navigates the current window to the URL instead of
+	// opening a popup.
+	WindowOpenSameTab WindowOpenPolicy = "same-tab"
+
+	// WindowOpenBlock doesn't open anything, making window.open return null.
+	WindowOpenBlock WindowOpenPolicy = "block"
+)
+
+// windowOpenBinding is the name of the binding used by HandleWindowOpen to
+// report window.open calls back from the page.
+const windowOpenBinding = "chromedpWindowOpen"
+
+// HandleWindowOpen is an action that makes the current target apply the policy
+// to every window.open call from then on, in the current document and in the
+// ones navigated to later. When fn is not nil, it's called with the resolved
+// URL of each call. Each call replaces the policy and fn of the previous one.
+//
+// Useful to test popups deterministically, as each allowed popup otherwise
+// spawns a new target. Note that fn is called synchronously from the target's
+// event loop, so it must not block nor run actions on the same target; see
+// ListenTarget. Popups opened via links with a target attribute are not
+// handled.
+func HandleWindowOpen(policy WindowOpenPolicy, fn func(url string)) NavigateAction {
+	return ActionFunc(func(ctx context.Context) error {
+		switch policy {
+		case WindowOpenAllow, WindowOpenSameTab, WindowOpenBlock:
+		default:
+			return fmt.Errorf("invalid window.open policy %q", policy)
+		}
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		t.emulationMu.Lock()
+		defer t.emulationMu.Unlock()
+
+		if t.windowOpen.cancel != nil {
+			t.windowOpen.cancel()
+			t.windowOpen.cancel = nil
+		}
+		if fn != nil {
+			// the listener outlives ctx, until the next call or until
+			// the target is gone
+			lctx, cancel := context.WithCancel(t.ctx)
+			t.listenersMu.Lock()
+			t.listeners = append(t.listeners, cancelableListener{lctx, func(ev interface{}) {
+				e, ok := ev.(*runtime.EventBindingCalled)
+				if !ok || e.Name != windowOpenBinding {
+					return
+				}
+				fn(e.Payload)
+			}})
+			t.listenersMu.Unlock()
+			t.windowOpen.cancel = cancel
+		}
+
+		if err := runtime.AddBinding(windowOpenBinding).Do(ctx); err != nil {
+			return err
+		}
+		bindingJSON, err := json.Marshal(windowOpenBinding)
+		if err != nil {
+			return err
+		}
+		policyJSON, err := json.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		hook := fmt.Sprintf(windowOpenHookJS, bindingJSON, policyJSON)
+		if err := replaceInitScript(ctx, &t.windowOpen.scriptID, hook); err != nil {
+			return err
+		}
+		return Evaluate(hook, &[]byte{}).Do(ctx)
+	})
+}
+
+// windowOpenState is the state of the last HandleWindowOpen action of a
+// target.
+type windowOpenState struct {
+	// scriptID is the script installing the hook in new documents.
+	scriptID page.ScriptIdentifier
+
+	// cancel removes the listener calling the fn of the last call.
+	cancel context.CancelFunc
+}
+
 // Stop is an action that stops all navigation and pending resource retrieval.
 func Stop() NavigateAction {
 	return page.StopLoading()
@@ -131,6 +775,28 @@ func CaptureScreenshot(res *[]byte) Action {
 	})
 }
 
+// ForceEagerImages is an action that makes the images and iframes of the
+// current document, and of any document loaded afterwards, load eagerly even
+// when they are marked as lazily loaded (ie, loading="lazy"). The current
+// document is then scrolled through, to also trigger the lazy loading done by
+// scripts that observe the scroll position, and the action waits until all of
+// its images have loaded.
+//
+// Useful before taking a screenshot of an entire page, as lazily loaded images
+// otherwise stay blank outside of the viewport.
+func ForceEagerImages() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if _, err := page.AddScriptToEvaluateOnNewDocument(eagerImagesJS).Do(ctx); err != nil {
+			return err
+		}
+		if err := Evaluate(eagerImagesJS, &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+		var res bool
+		return Evaluate(scrollLoadJS, &res, evalAwaitPromise).Do(ctx)
+	})
+}
+
 // Location is an action that retrieves the document location.
 func Location(urlstr *string) Action {
 	if urlstr == nil {
@@ -139,6 +805,61 @@ func Location(urlstr *string) Action {
 	return EvaluateAsDevTools(`document.location.toString()`, urlstr)
 }
 
+// BaseURL is an action that retrieves the base URL of the document, used to
+// resolve its relative URLs. It's the URL of the document, unless it's
+// overridden via a base element.
+func BaseURL(urlstr *string) Action {
+	if urlstr == nil {
+		panic("urlstr cannot be nil")
+	}
+	return EvaluateAsDevTools(`document.baseURI`, urlstr)
+}
+
+// SetBaseURL is an action that sets the base URL of the document, replacing the
+// href of its base element, or inserting one into its head when there's none.
+//
+// Only the URLs resolved afterwards are affected; for example, images already
+// loaded aren't loaded again. To render a document with a base URL from the
+// start, see SetContentBaseURL.
+func SetBaseURL(urlstr string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		urlJSON, err := json.Marshal(urlstr)
+		if err != nil {
+			return err
+		}
+		return EvaluateAsDevTools(fmt.Sprintf(setBaseURLJS, urlJSON), &[]byte{}).Do(ctx)
+	})
+}
+
+// Direction is an action that retrieves the direction of the document, as
+// resolved from the dir attribute of its root element: either "ltr" or "rtl".
+func Direction(dir *string) Action {
+	if dir == nil {
+		panic("dir cannot be nil")
+	}
+	return EvaluateAsDevTools(`getComputedStyle(document.documentElement).direction`, dir)
+}
+
+// SetDirection is an action that sets the dir attribute of the root element of
+// the document to dir, which must be one of "ltr", "rtl" or "auto", reflowing
+// its layout accordingly; see Direction. Any other dir makes the action return
+// an error.
+//
+// Useful to test right-to-left layouts, such as for Arabic or Hebrew, without
+// changing the locale of the browser. Only the current document is affected,
+// and the page's own language settings, such as its lang attribute, are left
+// unchanged.
+func SetDirection(dir string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		switch dir {
+		case "ltr", "rtl", "auto":
+		default:
+			return fmt.Errorf("invalid direction %q", dir)
+		}
+		return EvaluateAsDevTools(fmt.Sprintf(`document.documentElement.dir = %q`, dir), &[]byte{}).Do(ctx)
+	})
+}
+
 // Title is an action that retrieves the document title.
 func Title(title *string) Action {
 	if title == nil {
@@ -147,6 +868,66 @@ func Title(title *string) Action {
 	return EvaluateAsDevTools(`document.title`, title)
 }
 
+// WaitTitle is an action that waits until the document title satisfies match.
+//
+// Instead of polling, the action waits for the document title to change via a
+// MutationObserver, and calls match each time it does. Useful for pages that
+// set their title once their content is ready.
+func WaitTitle(match func(title string) bool) Action {
+	if match == nil {
+		panic("match cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var title string
+		if err := Title(&title).Do(ctx); err != nil {
+			return err
+		}
+		for !match(title) {
+			if err := Evaluate(fmt.Sprintf(titleChangeJS, title), &title, evalAwaitPromise).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WaitPage is an action that waits until the document title matches
+// titlePattern, the document URL matches urlPattern, and an element matching
+// the CSS selector sel exists, all at the same time. A nil pattern, or an
+// empty selector, matches any page.
+//
+// Useful as a single readiness gate after a navigation, to check that the
+// expected page was loaded. The three conditions are checked by a single
+// evaluation, so that they can't hold for different documents. Evaluations
+// failing while the page navigates are retried.
+func WaitPage(titlePattern, urlPattern *regexp.Regexp, sel string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		selJSON, err := json.Marshal(sel)
+		if err != nil {
+			return err
+		}
+		expr := fmt.Sprintf(pageStateJS, selJSON)
+
+		return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			var res struct {
+				Title string `json:"title"`
+				URL   string `json:"url"`
+				Found bool   `json:"found"`
+			}
+			if err := Evaluate(expr, &res).Do(ctx); err != nil {
+				if _, ok := err.(*runtime.ExceptionDetails); ok {
+					return false, err
+				}
+				return false, nil
+			}
+			return res.Found &&
+				(titlePattern == nil || titlePattern.MatchString(res.Title)) &&
+				(urlPattern == nil || urlPattern.MatchString(res.URL)), nil
+		})
+	})
+}
+
 type isExpectedEvent func(i interface{}) bool
 
 type expectFunc func() error
@@ -194,10 +975,9 @@ func navigatedFrameID(ctx context.Context) cdp.FrameID {
 		return ""
 	}
 	target.curMu.RLock()
+	defer target.curMu.RUnlock()
 	if target.cur == nil {
 		return ""
 	}
-	id := target.cur.ID
-	target.curMu.RUnlock()
-	return id
+	return target.cur.ID
 }
@@ -3,16 +3,22 @@ package chromedp
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"image"
 	_ "image/png"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 )
 
@@ -38,6 +44,229 @@ func TestNavigate(t *testing.T) {
 	}
 }
 
+func TestNavigateMaxRedirects(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/loop/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop/b", http.StatusFound)
+	})
+	mux.HandleFunc("/loop/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop/a", http.StatusFound)
+	})
+	mux.HandleFunc("/once", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.Handle("/final", writeHTML(`<title>final</title>`))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL+"/once", NavigateMaxRedirects(3))); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Run(ctx, Navigate(s.URL+"/loop/a", NavigateMaxRedirects(3)))
+	if err == nil {
+		t.Fatal("expected an error for a redirect loop")
+	}
+	if want := s.URL + "/loop/a -> " + s.URL + "/loop/b"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to contain the redirect chain %q, got: %v", want, err)
+	}
+}
+
+func TestRedirectChain(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/first", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/second", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/second", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.Handle("/final", writeHTML(`<title>final</title>`))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var chain []string
+	if err := Run(ctx,
+		network.Enable(),
+		Navigate(s.URL+"/first"),
+		RedirectChain(&chain),
+	); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{s.URL + "/first", s.URL + "/second", s.URL + "/final"}
+	if !reflect.DeepEqual(chain, want) {
+		t.Errorf("expected redirect chain %v, got: %v", want, chain)
+	}
+
+	if err := Run(ctx,
+		Navigate(s.URL+"/final"),
+		RedirectChain(&chain),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{s.URL + "/final"}; !reflect.DeepEqual(chain, want) {
+		t.Errorf("expected redirect chain %v, got: %v", want, chain)
+	}
+}
+
+func TestAutoHandleBeforeUnload(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<title>unsaved</title>
+<input id="edit" type="text">
+<script>
+	window.addEventListener('beforeunload', function(e) {
+		e.preventDefault();
+		e.returnValue = 'unsaved changes';
+	});
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var title string
+	if err := Run(ctx,
+		AutoHandleBeforeUnload(true),
+		Navigate(s.URL),
+		// the dialog is only shown after the user interacted with the page
+		Click(`#edit`, ByID),
+		Navigate(testdataDir+"/image.html"),
+		Title(&title),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "this is title"; title != want {
+		t.Errorf("expected title %q, got: %q", want, title)
+	}
+}
+
+func TestWaitPageStable(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<style>
+	@keyframes grow { from { width: 0; } to { width: 100px; } }
+	#box { height: 10px; background: red; animation: grow 300ms forwards; }
+</style>
+<div id="box"></div>
+<script>
+	setTimeout(function() {
+		var el = document.createElement('div');
+		el.id = 'late';
+		el.textContent = 'late';
+		document.body.appendChild(el);
+	}, 100);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var width float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitPageStable(`#late`, ByID),
+		Evaluate(`document.getElementById('box').getBoundingClientRect().width`, &width),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if width != 100 {
+		t.Errorf("expected the animation to have finished, got width: %f", width)
+	}
+}
+
+func TestNavigateWithBFCache(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/uncacheable", writeHTML(`<title>uncacheable</title>
+<script>window.addEventListener('unload', function() {});</script>`))
+	mux.Handle("/other", writeHTML(`<title>other</title>`))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// pages with unload handlers are never restored from the cache
+	var restored bool
+	var title string
+	if err := Run(ctx,
+		Navigate(s.URL+"/uncacheable"),
+		NavigateWithBFCache(s.URL+"/other", &restored),
+		Title(&title),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if restored {
+		t.Error("expected the page with an unload handler not to be restored")
+	}
+	if want := "uncacheable"; title != want {
+		t.Errorf("expected to be back on %q, got: %q", want, title)
+	}
+}
+
+func TestSetContent(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var title string
+	var width int
+	if err := Run(ctx,
+		SetContent(`<!doctype html><title>content</title><img id="img" src="images/github.png">`,
+			SetContentBaseURL(testdataDir+"/")),
+		Title(&title),
+		Evaluate(`document.getElementById('img').naturalWidth`, &width),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "content"; title != want {
+		t.Errorf("expected title %q, got: %q", want, title)
+	}
+	if width == 0 {
+		t.Error("expected the image to have loaded relative to the base URL")
+	}
+}
+
+func TestNavigateLocalURL(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var title, urlstr string
+	if err := Run(ctx,
+		Navigate(`data:text/html,<title>data url</title><p>inline</p>`),
+		Title(&title),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "data url"; title != want {
+		t.Errorf("expected title %q, got: %q", want, title)
+	}
+
+	if err := Run(ctx,
+		Navigate("about:blank"),
+		Location(&urlstr),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "about:blank"; urlstr != want {
+		t.Errorf("expected to be on %q, at %q", want, urlstr)
+	}
+}
+
 func TestNavigationEntries(t *testing.T) {
 	t.Parallel()
 
@@ -268,6 +497,76 @@ func TestTitle(t *testing.T) {
 	}
 }
 
+func TestSetDirection(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p id="text" style="display: inline-block">&#1605;&#1585;&#1581;&#1576;&#1575;</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const left = `document.getElementById('text').getBoundingClientRect().left`
+	var before, after, auto string
+	var ltrLeft, rtlLeft float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Direction(&before),
+		Evaluate(left, &ltrLeft),
+		SetDirection("rtl"),
+		Direction(&after),
+		Evaluate(left, &rtlLeft),
+		SetDirection("auto"),
+		Direction(&auto),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if before != "ltr" || after != "rtl" {
+		t.Errorf("expected the direction to change from ltr to rtl, got: %q and %q", before, after)
+	}
+	if rtlLeft <= ltrLeft {
+		t.Errorf("expected the text to move to the right, got: %v and %v", ltrLeft, rtlLeft)
+	}
+	// the text of the document starts with Arabic characters
+	if auto != "rtl" {
+		t.Errorf("expected the automatic direction to be rtl, got: %q", auto)
+	}
+
+	if err := Run(ctx, SetDirection("up")); err == nil {
+		t.Error("expected an error for an invalid direction")
+	}
+}
+
+func TestWaitTitle(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<head><title>loading</title></head>
+<script>
+	setTimeout(function() { document.title = 'still loading'; }, 50);
+	setTimeout(function() { document.title = 'ready: 3 items'; }, 100);
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var seen []string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitTitle(func(title string) bool {
+			seen = append(seen, title)
+			return strings.HasPrefix(title, "ready")
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := seen[len(seen)-1], "ready: 3 items"; got != want {
+		t.Fatalf("expected last title to be %q, got %q", want, got)
+	}
+}
+
 func TestLoadIframe(t *testing.T) {
 	t.Parallel()
 
@@ -378,3 +677,413 @@ func TestNavigateWithoutWaitingForLoad(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestWaitPage(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<title>Loading</title>
+<script>
+	setTimeout(function() { history.pushState(null, '', '/dashboard'); }, 100);
+	setTimeout(function() { document.title = 'Dashboard'; }, 200);
+	setTimeout(function() {
+		var el = document.createElement('main');
+		el.id = 'widgets';
+		document.body.appendChild(el);
+	}, 300);
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var title, url string
+	var found bool
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitPage(regexp.MustCompile(`^Dashboard$`), regexp.MustCompile(`/dashboard$`), "#widgets"),
+		Title(&title),
+		Location(&url),
+		Evaluate(`document.querySelector('#widgets') !== null`, &found),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if title != "Dashboard" || url != s.URL+"/dashboard" || !found {
+		t.Errorf("expected the dashboard to be loaded, got: %q %q %v", title, url, found)
+	}
+}
+
+func TestHandleWindowOpen(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`
+<button id="open" onclick="window.opened = window.open('popup?x=1')">open</button>
+	`))
+	mux.Handle("/popup", writeHTML(`<p>popup</p>`))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	urls := make(chan string, 1)
+	var blocked bool
+	if err := Run(ctx,
+		Navigate(s.URL),
+		HandleWindowOpen(WindowOpenBlock, func(url string) {
+			urls <- url
+		}),
+		Click(`#open`, ByID),
+		Evaluate(`window.opened === null`, &blocked),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !blocked {
+		t.Error("expected window.open to be blocked")
+	}
+	select {
+	case url := <-urls:
+		if want := s.URL + "/popup?x=1"; url != want {
+			t.Errorf("expected the popup URL %q, got: %q", want, url)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the popup URL to be reported")
+	}
+
+	// switching the policy replaces the previous one and its fn, both in
+	// the current document and in the next ones
+	var location string
+	if err := Run(ctx,
+		HandleWindowOpen(WindowOpenSameTab, nil),
+		Reload(),
+		Click(`#open`, ByID),
+		WaitNotPresent(`#open`, ByID),
+		Location(&location),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := s.URL + "/popup?x=1"; location != want {
+		t.Errorf("expected the switched policy to open the popup in the same tab at %q, got: %q", want, location)
+	}
+	select {
+	case url := <-urls:
+		t.Errorf("expected the replaced fn not to be called, got: %q", url)
+	default:
+	}
+
+	ctx, cancel = NewContext(ctx)
+	defer cancel()
+
+	if err := Run(ctx,
+		HandleWindowOpen(WindowOpenSameTab, nil),
+		Navigate(s.URL),
+		Click(`#open`, ByID),
+		WaitNotPresent(`#open`, ByID),
+		Location(&location),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := s.URL + "/popup?x=1"; location != want {
+		t.Errorf("expected the popup to open in the same tab at %q, got: %q", want, location)
+	}
+}
+
+func TestForceEagerImages(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`
+<div style="height: 5000px"></div>
+<img id="lazy" loading="lazy" src="/images/github.png">
+<img id="scrolled">
+<script>
+	new IntersectionObserver(function(entries) {
+		if (entries[0].isIntersecting) {
+			entries[0].target.src = '/images/github.png?scrolled';
+		}
+	}).observe(document.getElementById('scrolled'));
+</script>
+	`))
+	mux.Handle("/images/", http.FileServer(http.Dir("testdata")))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const loadedJS = `[document.getElementById('lazy').naturalWidth > 0, document.getElementById('scrolled').naturalWidth > 0, window.scrollY]`
+	var loaded []interface{}
+	if err := Run(ctx,
+		EmulateViewport(800, 600),
+		Navigate(s.URL),
+		ForceEagerImages(),
+		Evaluate(loadedJS, &loaded),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{true, true, 0.0}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("expected all images to be loaded with the scroll position restored, got: %v", loaded)
+	}
+	var loading string
+	if err := Run(ctx,
+		Reload(),
+		Evaluate(`document.getElementById('lazy').loading`, &loading),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if loading != "eager" {
+		t.Errorf("expected the image to be eager after reloading, got: %q", loading)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p>app</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, after int64
+	var initial, state json.RawMessage
+	var mode string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		HistoryLength(&before),
+		HistoryState(&initial),
+		Evaluate(`history.pushState({route: 'settings'}, '', '/settings')`, &[]byte{}),
+		HistoryLength(&after),
+		HistoryState(&state),
+		SetScrollRestoration("manual"),
+		ScrollRestoration(&mode),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if after != before+1 {
+		t.Errorf("expected the history length to grow from %d, got: %d", before, after)
+	}
+	if string(initial) != "null" {
+		t.Errorf("expected a null initial state, got: %s", initial)
+	}
+	if want := `{"route":"settings"}`; string(state) != want {
+		t.Errorf("expected state %s, got: %s", want, state)
+	}
+	if mode != "manual" {
+		t.Errorf("expected manual scroll restoration, got: %q", mode)
+	}
+}
+
+func TestPushState(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p id="route"></p>
+<script>
+	window.addEventListener('popstate', function(e) {
+		document.getElementById('route').textContent = location.pathname + ' ' + JSON.stringify(e.state);
+	});
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var path, pushed, replaced string
+	var before, after int64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		HistoryLength(&before),
+		PushState("/users/1", map[string]int{"id": 1}),
+		Evaluate(`location.pathname + ' ' + document.getElementById('route').textContent`, &path),
+		DispatchPopState(),
+		Text(`#route`, &pushed, ByID),
+		ReplaceState("/users/2", map[string]int{"id": 2}),
+		DispatchPopState(),
+		Text(`#route`, &replaced, ByID),
+		HistoryLength(&after),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := `/users/1 `; path != want {
+		t.Errorf("expected the URL to change without a popstate event, got: %q", path)
+	}
+	if want := `/users/1 {"id":1}`; pushed != want {
+		t.Errorf("expected the route %q after pushing, got: %q", want, pushed)
+	}
+	if want := `/users/2 {"id":2}`; replaced != want {
+		t.Errorf("expected the route %q after replacing, got: %q", want, replaced)
+	}
+	if after != before+1 {
+		t.Errorf("expected a single history entry to be added, got: %d", after-before)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<a id="link" href="page.html">page</a>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, after, link string
+	if err := Run(ctx,
+		Navigate(s.URL+"/docs/"),
+		BaseURL(&before),
+		SetBaseURL("https://example.com/assets/"),
+		BaseURL(&after),
+		Evaluate(`document.getElementById('link').href`, &link),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := s.URL + "/docs/"; before != want {
+		t.Errorf("expected the document URL %q as the base, got: %q", want, before)
+	}
+	if want := "https://example.com/assets/"; after != want {
+		t.Errorf("expected the base URL %q, got: %q", want, after)
+	}
+	if want := "https://example.com/assets/page.html"; link != want {
+		t.Errorf("expected the link to resolve to %q, got: %q", want, link)
+	}
+}
+
+func TestNavigateChain(t *testing.T) {
+	t.Parallel()
+
+	referrers := make(chan string, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/favicon.ico" {
+			http.NotFound(w, r)
+			return
+		}
+		referrers <- r.URL.Path + " " + r.Header.Get("Referer")
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<p>page</p>`)
+	}))
+	defer s.Close()
+	cross := strings.Replace(s.URL, "127.0.0.1", "localhost", 1)
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{"origin-when-cross-origin", []string{
+			"/a ",
+			"/b " + s.URL + "/a?x=1",
+			"/c " + s.URL + "/",
+		}},
+		{"no-referrer", []string{"/a ", "/b ", "/c "}},
+	}
+	for _, test := range tests {
+		if err := Run(ctx, NavigateChain([]string{
+			s.URL + "/a?x=1#frag",
+			s.URL + "/b",
+			cross + "/c",
+		}, test.policy)); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range test.want {
+			if got := <-referrers; got != want {
+				t.Errorf("%s: expected %q, got: %q", test.policy, want, got)
+			}
+		}
+	}
+
+	if err := Run(ctx, NavigateChain([]string{s.URL}, "invalid")); err == nil {
+		t.Error("expected an error for an invalid referrer policy")
+	}
+}
+
+func TestNavigateWithRetry(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/favicon.ico" {
+			http.NotFound(w, r)
+			return
+		}
+		// reset the connection for the first two requests
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<p id="ok">ok</p>`)
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, NavigateWithRetry(s.URL, 2, 10*time.Millisecond)); err == nil {
+		t.Error("expected an error after two failed attempts")
+	}
+	atomic.StoreInt32(&requests, 0)
+
+	var text string
+	if err := Run(ctx,
+		NavigateWithRetry(s.URL, 3, 10*time.Millisecond),
+		Text(`#ok`, &text, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if text != "ok" {
+		t.Errorf("expected the page to load, got: %q", text)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expected 3 requests, got: %d", n)
+	}
+
+	start := time.Now()
+	err := Run(ctx, NavigateWithRetry("http://chromedp.invalid/", 3, time.Second))
+	if err == nil || !strings.Contains(err.Error(), "ERR_NAME_NOT_RESOLVED") {
+		t.Errorf("expected a name resolution error, got: %v", err)
+	}
+	if time.Since(start) >= time.Second {
+		t.Error("expected no retries for a name resolution error")
+	}
+}
+
+func TestNavigateAndCaptureBody(t *testing.T) {
+	t.Parallel()
+
+	const served = `<p id="rendered">server</p><script>document.getElementById('rendered').textContent = 'client';</script>`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.Handle("/page", writeHTML(served))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body []byte
+	var text string
+	if err := Run(ctx,
+		NavigateAndCaptureBody(s.URL, &body),
+		Text(`#rendered`, &text, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != served {
+		t.Errorf("expected the body served for the document, got: %q", body)
+	}
+	if text != "client" {
+		t.Errorf("expected the document to be rendered by the script, got: %q", text)
+	}
+}
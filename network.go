@@ -0,0 +1,610 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// ResponseBody is an action that retrieves the body of the last response
+// received by the current target for a URL matching urlPattern, without
+// requesting the resource again.
+//
+// Note: responses are only recorded while the Network domain is enabled, so
+// network.Enable must be run before the resource is fetched by the page. Only
+// the last 100 responses which finished loading are kept track of, and the
+// browser may evict the bodies of old responses from its buffers.
+func ResponseBody(urlPattern *regexp.Regexp, body *[]byte) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	if body == nil {
+		panic("body cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		req := t.lastResponse(func(req *trackedRequest) bool {
+			return urlPattern.MatchString(req.res.Response.URL)
+		})
+		if req == nil {
+			return fmt.Errorf("no response received for a URL matching %q", urlPattern)
+		}
+
+		var err error
+		*body, err = network.GetResponseBody(req.res.RequestID).Do(ctx)
+		return err
+	})
+}
+
+// ResponseEncoding is an action that retrieves the Content-Encoding header of
+// the last response received by the current target for a URL matching
+// urlPattern, such as "gzip" or "br". The encoding is empty if the response
+// wasn't compressed.
+//
+// Note: responses are only recorded while the Network domain is enabled, so
+// network.Enable must be run before the resource is fetched by the page.
+func ResponseEncoding(urlPattern *regexp.Regexp, encoding *string) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	if encoding == nil {
+		panic("encoding cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		req := t.lastResponse(func(req *trackedRequest) bool {
+			return urlPattern.MatchString(req.res.Response.URL)
+		})
+		if req == nil {
+			return fmt.Errorf("no response received for a URL matching %q", urlPattern)
+		}
+
+		var headers map[string]string
+		if err := json.Unmarshal(req.res.Response.Headers, &headers); err != nil {
+			return err
+		}
+		*encoding = ""
+		for name, value := range headers {
+			if strings.EqualFold(name, "Content-Encoding") {
+				*encoding = value
+			}
+		}
+		return nil
+	})
+}
+
+// ResponseSetCookies is an action that retrieves the raw Set-Cookie headers of
+// the last response received by the current target for a URL matching
+// urlPattern, exactly as sent by the server, including the attributes of the
+// cookies such as SameSite and Max-Age. The cookies are retrieved even if they
+// were blocked by the browser.
+//
+// Note: the raw headers of responses are only recorded while the Network
+// domain is enabled, so network.Enable must be run before the resource is
+// fetched by the page.
+func ResponseSetCookies(urlPattern *regexp.Regexp, cookies *[]string) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	if cookies == nil {
+		panic("cookies cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		req := t.lastResponse(func(req *trackedRequest) bool {
+			return urlPattern.MatchString(req.res.Response.URL)
+		})
+		if req == nil {
+			return fmt.Errorf("no response received for a URL matching %q", urlPattern)
+		}
+		if req.rawHeaders == nil {
+			return fmt.Errorf("no raw headers received for %q", req.res.Response.URL)
+		}
+		var headers map[string]string
+		if err := json.Unmarshal(req.rawHeaders, &headers); err != nil {
+			return err
+		}
+
+		// multiple headers with the same name are joined by newlines
+		var lines []string
+		for name, value := range headers {
+			if strings.EqualFold(name, "Set-Cookie") {
+				lines = append(lines, strings.Split(value, "\n")...)
+			}
+		}
+		*cookies = lines
+		return nil
+	})
+}
+
+// WaitNetworkIdle is an action that enables the Network domain, and then waits
+// until the current target has had at most maxInflight requests in flight for
+// the quiet duration.
+//
+// Unlike the "networkIdle" lifecycle event, the number of requests allowed to
+// be in flight can be adjusted, which is useful for pages that keep long-lived
+// connections open.
+//
+// Note: requests which started before the Network domain was enabled are not
+// accounted for, so network.Enable should be run before navigating if such
+// requests need to be waited for.
+func WaitNetworkIdle(maxInflight int, quiet time.Duration) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		var idleSince time.Time
+		return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			if t.inflightRequests() > maxInflight {
+				idleSince = time.Time{}
+				return false, nil
+			}
+			if idleSince.IsZero() {
+				idleSince = time.Now()
+			}
+			return time.Since(idleSince) >= quiet, nil
+		})
+	})
+}
+
+// WaitResourceLoaded is an action that enables the Network domain, and then
+// waits until a resource of resourceType, with a URL matching urlPattern, has
+// finished loading in the current target. An empty resourceType matches
+// resources of any type.
+//
+// Only the resources requested after this action has started are matched, so
+// that waiting again for the same resource waits for it to be loaded again.
+// As such, resources requested by the previous actions, such as a script
+// requested synchronously when clicking an element, are not waited for.
+func WaitResourceLoaded(urlPattern *regexp.Regexp, resourceType network.ResourceType) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		since := t.lastNetworkSeq()
+		return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			req := t.lastResponse(func(req *trackedRequest) bool {
+				return req.loaded && req.sent > since && (resourceType == "" || req.res.Type == resourceType) &&
+					urlPattern.MatchString(req.res.Response.URL)
+			})
+			return req != nil, nil
+		})
+	})
+}
+
+// WaitChunkLoaded is an action that waits until a script with a URL matching
+// namePattern, such as a code-split chunk loaded via a dynamic import when
+// navigating to a lazy-loaded route, has finished loading in the current
+// target. It is a shorthand for WaitResourceLoaded with
+// network.ResourceTypeScript, so only the scripts requested after this action
+// has started are matched.
+func WaitChunkLoaded(namePattern *regexp.Regexp) Action {
+	return WaitResourceLoaded(namePattern, network.ResourceTypeScript)
+}
+
+// WaitJSONResponse is an action that enables the Network domain, and then
+// waits until a response for a URL matching urlPattern has finished loading in
+// the current target, unmarshaling the JSON body of the last such response into
+// out, as with json.Unmarshal. Useful to assert the payloads of the API calls
+// triggered by an action on the page.
+//
+// Note: network.Enable should be run before the response is received, or it
+// may be missed; unlike with WaitResourceLoaded, responses which were received
+// before this action is run match as well.
+func WaitJSONResponse(urlPattern *regexp.Regexp, out interface{}) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		var res *network.EventResponseReceived
+		if err := waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			req := t.lastResponse(func(req *trackedRequest) bool {
+				return req.loaded && urlPattern.MatchString(req.res.Response.URL)
+			})
+			if req == nil {
+				return false, nil
+			}
+			res = req.res
+			return true, nil
+		}); err != nil {
+			return err
+		}
+
+		body, err := network.GetResponseBody(res.RequestID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("could not decode the response from %q: %v", res.Response.URL, err)
+		}
+		return nil
+	})
+}
+
+// AssertNoRequest is an action that enables the Network domain, and then
+// watches the requests made by the current target for the specified duration,
+// returning an error as soon as one is made for a URL matching urlPattern.
+//
+// Useful for asserting that a resource, such as a tracker blocked by a privacy
+// setting, is never loaded. Only the requests made while the action runs are
+// watched, so it should be run right after the action expected to trigger the
+// request, if any.
+func AssertNoRequest(urlPattern *regexp.Regexp, within time.Duration) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		ch := make(chan string, 1)
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*network.EventRequestWillBeSent)
+			if !ok || !urlPattern.MatchString(e.Request.URL) {
+				return
+			}
+			select {
+			case ch <- e.Request.URL:
+				cancel()
+			default:
+			}
+		})
+
+		if err := network.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(within)
+		defer timer.Stop()
+		select {
+		case url := <-ch:
+			return fmt.Errorf("unexpected request to %q", url)
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// BeaconCall is a beacon sent by a page, as captured by CaptureBeacons.
+type BeaconCall struct {
+	// Type is "beacon" for navigator.sendBeacon calls, and "ping" for the
+	// pings sent when following links with a ping attribute.
+	Type string `json:"type"`
+
+	// URL is the resolved URL the beacon is sent to.
+	URL string `json:"url"`
+
+	// Payload is the data sent via navigator.sendBeacon, decoded as text.
+	// Pings have no payload.
+	Payload string `json:"payload"`
+}
+
+// beaconBinding is the name of the binding used by CaptureBeacons to report
+// beacons back from the page.
+const beaconBinding = "chromedpBeacon"
+
+// CaptureBeacons is an action that makes the current target record every
+// navigator.sendBeacon call, and every ping sent when clicking a link with a
+// ping attribute, from then on, in the current document and in the ones
+// navigated to later, appending them to out. The beacons are still sent.
+//
+// Useful to check that analytics fire on specific interactions, as beacons
+// may still be in flight, or be sent while the page unloads, when the Network
+// domain would report them. Note that out is appended to from the target's
+// event loop, so it should only be read once the actions triggering the
+// beacons have run.
+func CaptureBeacons(out *[]BeaconCall) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		ListenTarget(ctx, func(ev interface{}) {
+			e, ok := ev.(*runtime.EventBindingCalled)
+			if !ok || e.Name != beaconBinding {
+				return
+			}
+			var call BeaconCall
+			if err := json.Unmarshal([]byte(e.Payload), &call); err == nil {
+				*out = append(*out, call)
+			}
+		})
+
+		if err := runtime.AddBinding(beaconBinding).Do(ctx); err != nil {
+			return err
+		}
+		hook := fmt.Sprintf(beaconHookJS, beaconBinding)
+		if _, err := page.AddScriptToEvaluateOnNewDocument(hook).Do(ctx); err != nil {
+			return err
+		}
+		return Evaluate(hook, &[]byte{}).Do(ctx)
+	})
+}
+
+// SetAcceptHeader is an action that enables the Fetch domain, and then
+// overrides the Accept header of every request made by the current target for
+// a URL matching urlPattern with accept. Other requests are continued
+// unmodified.
+//
+// Useful to force an endpoint that performs content negotiation to respond
+// with a specific type, such as "application/json".
+//
+// Note: the header is overridden until ctx is cancelled. The headers set by
+// other actions for the same request, such as SetOrigin, are merged.
+func SetAcceptHeader(urlPattern *regexp.Regexp, accept string) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	return overrideRequestHeader(urlPattern, "Accept", accept)
+}
+
+// SetAcceptEncoding is an action that enables the Fetch domain, and then
+// overrides the Accept-Encoding header of every request made by the current
+// target for a URL matching urlPattern with encoding, such as "identity" to
+// request uncompressed responses, or "br" to only accept Brotli. Other
+// requests are continued unmodified.
+//
+// Useful along with ResponseEncoding to verify that a server compresses its
+// responses according to the client's capabilities. The header is overridden
+// until ctx is cancelled.
+func SetAcceptEncoding(urlPattern *regexp.Regexp, encoding string) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	return overrideRequestHeader(urlPattern, "Accept-Encoding", encoding)
+}
+
+// SetOrigin is an action that enables the Fetch domain, and then sets the
+// Origin header of every request made by the current target for a URL
+// matching urlPattern to origin, such as "https://example.com". Other requests
+// are continued unmodified.
+//
+// Useful for testing how a server handles CORS requests from various origins,
+// without serving a page from each of them. Note that the browser still
+// checks the CORS headers of the responses against the page's actual origin.
+// The header is overridden until ctx is cancelled.
+func SetOrigin(urlPattern *regexp.Regexp, origin string) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	return overrideRequestHeader(urlPattern, "Origin", origin)
+}
+
+// overrideRequestHeader enables the Fetch domain, and then overrides the
+// header with the specified name of every request for a URL matching
+// urlPattern.
+func overrideRequestHeader(urlPattern *regexp.Regexp, name, value string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageRequest,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return urlPattern.MatchString(e.Request.URL)
+			},
+			modify: func(ctx context.Context, p *pausedRequest) error {
+				p.setHeader(name, value)
+				return nil
+			},
+		})
+	})
+}
+
+// SetDynamicHeaders is an action that enables the Fetch domain, and then calls
+// fn for every request made by the current target, merging the headers it
+// returns into the headers of the request, replacing the ones with the same
+// name. Requests for which fn returns no headers are continued unmodified.
+//
+// Useful for headers which depend on the request, such as a signature of its
+// URL for APIs which require signed requests. fn is called from a separate
+// goroutine for each request, so it may block. The headers are set until ctx
+// is cancelled, and are merged with the headers set by other actions for the
+// same request, such as SetAcceptHeader; fn sees the request's original
+// headers.
+func SetDynamicHeaders(fn func(req *fetch.EventRequestPaused) network.Headers) Action {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageRequest,
+			match: func(*fetch.EventRequestPaused) bool { return true },
+			modify: func(ctx context.Context, p *pausedRequest) error {
+				var set map[string]string
+				if extra := fn(p.EventRequestPaused); len(extra) > 0 {
+					if err := json.Unmarshal(extra, &set); err != nil {
+						return err
+					}
+				}
+				for name, value := range set {
+					p.setHeader(name, value)
+				}
+				return nil
+			},
+		})
+	})
+}
+
+// DelayRequests is an action that enables the Fetch domain, and then holds
+// every request made by the current target for a URL matching urlPattern for
+// the specified delay before continuing it. Other requests are continued
+// unmodified.
+//
+// Useful to simulate slow endpoints, such as to test loading indicators or
+// race conditions, without slowing down the rest of the page. Requests are
+// delayed until ctx is cancelled, at which point the held requests are
+// released early.
+func DelayRequests(urlPattern *regexp.Regexp, delay time.Duration) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageRequest,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return urlPattern.MatchString(e.Request.URL)
+			},
+			modify: func(ctx context.Context, p *pausedRequest) error {
+				timer := time.NewTimer(delay)
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				return nil
+			},
+		})
+	})
+}
+
+// ThrottleRequest is an action that enables the Fetch domain, and then caps
+// the download speed of every response received by the current target for a
+// URL matching urlPattern to bytesPerSec. Other responses are continued
+// unmodified.
+//
+// Useful to reproduce issues with a single slow resource, such as an asset
+// served by a slow CDN, while the rest of the page loads at full speed; see
+// network.EmulateNetworkConditions to throttle the whole page instead.
+//
+// As the Fetch domain can only fulfill a response with its whole body, the body
+// is held for as long as it would take to download at the capped speed, and is
+// then delivered at once, rather than streamed in chunks. The speed is capped
+// until ctx is cancelled, at which point the held responses are released
+// early.
+func ThrottleRequest(urlPattern *regexp.Regexp, bytesPerSec int) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	if bytesPerSec <= 0 {
+		panic("bytesPerSec must be positive")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageResponse,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return e.ResponseErrorReason == "" && urlPattern.MatchString(e.Request.URL)
+			},
+			modify: func(ctx context.Context, p *pausedRequest) error {
+				return throttleResponse(ctx, p, bytesPerSec)
+			},
+		})
+	})
+}
+
+// throttleResponse holds the paused response for the time it would take to
+// download its body at bytesPerSec.
+func throttleResponse(ctx context.Context, p *pausedRequest, bytesPerSec int) error {
+	body, err := p.responseBody(ctx)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Duration(len(body)) * time.Second / time.Duration(bytesPerSec))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OverrideEncoding is an action that enables the Fetch domain, and then
+// overrides the charset of the Content-Type header of every response received
+// by the current target for a URL matching urlPattern, so that the document is
+// decoded as charset (eg, "windows-1252" or "shift_jis"). Other responses are
+// continued unmodified.
+//
+// Useful for pages that declare the wrong character encoding, as the charset
+// of the Content-Type header takes precedence over the document's meta tags.
+// See Encoding to retrieve the encoding used to decode a document. The
+// encoding is overridden until ctx is cancelled.
+func OverrideEncoding(urlPattern *regexp.Regexp, charset string) Action {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		return intercept(ctx, &requestHandler{
+			stage: fetch.RequestStageResponse,
+			match: func(e *fetch.EventRequestPaused) bool {
+				return e.ResponseErrorReason == "" && urlPattern.MatchString(e.Request.URL)
+			},
+			modify: func(ctx context.Context, p *pausedRequest) error {
+				overrideCharset(p, charset)
+				return nil
+			},
+		})
+	})
+}
+
+// overrideCharset replaces the charset of the Content-Type header of the
+// paused response.
+func overrideCharset(p *pausedRequest, charset string) {
+	typ, params := "text/html", map[string]string{}
+	if v, ok := p.header("Content-Type"); ok {
+		if t, tp, err := mime.ParseMediaType(v); err == nil {
+			typ, params = t, tp
+		}
+	}
+	params["charset"] = charset
+	p.setHeader("Content-Type", mime.FormatMediaType(typ, params))
+}
@@ -0,0 +1,807 @@
+package chromedp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestResponseBody(t *testing.T) {
+	t.Parallel()
+
+	const script = `document.title = 'from script';`
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<script src="/script.js"></script>`))
+	mux.HandleFunc("/script.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte(script))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body []byte
+	if err := Run(ctx,
+		network.Enable(),
+		Navigate(s.URL),
+		ResponseBody(regexp.MustCompile(`/script\.js$`), &body),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != script {
+		t.Errorf("expected body %q, got: %q", script, body)
+	}
+
+	if err := Run(ctx, ResponseBody(regexp.MustCompile(`/missing\.js$`), &body)); err == nil {
+		t.Error("expected an error for a URL without a response")
+	}
+}
+
+func TestWaitNetworkIdle(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<script>
+	window.done = 0;
+	fetch('/slow?1').then(function() { window.done++; });
+	fetch('/slow?2').then(function() { window.done++; });
+</script>`))
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var done int
+	if err := Run(ctx,
+		network.Enable(),
+		Navigate(s.URL),
+		WaitNetworkIdle(0, 100*time.Millisecond),
+		Evaluate(`window.done`, &done),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if done != 2 {
+		t.Errorf("expected both requests to be done, got: %d", done)
+	}
+}
+
+func TestWaitResourceLoaded(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<script>
+	function load() {
+		var s = document.createElement('script');
+		s.src = '/sdk.js';
+		document.head.appendChild(s);
+	}
+	setTimeout(load, 500);
+	setTimeout(load, 1000);
+</script>`))
+	var requests int32
+	mux.HandleFunc("/sdk.js", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte(`window.sdkReady = true;`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body []byte
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitResourceLoaded(regexp.MustCompile(`/sdk\.js$`), network.ResourceTypeScript),
+		ResponseBody(regexp.MustCompile(`/sdk\.js$`), &body),
+		// waiting again waits for the script to be loaded again
+		WaitResourceLoaded(regexp.MustCompile(`/sdk\.js$`), network.ResourceTypeScript),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := `window.sdkReady = true;`; string(body) != want {
+		t.Errorf("expected body %q, got: %q", want, body)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected the script to be loaded twice, got: %d", got)
+	}
+}
+
+func TestWaitChunkLoaded(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<button id="route" onclick="setTimeout(function() { import('/chunk-settings.js'); }, 300)">settings</button>`))
+	mux.HandleFunc("/chunk-settings.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte(`window.settingsLoaded = true;`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var loaded bool
+	if err := Run(ctx,
+		Navigate(s.URL),
+		network.Enable(),
+		Click("#route", ByQuery),
+		WaitChunkLoaded(regexp.MustCompile(`/chunk-settings\.js$`)),
+		// the module is cached, so it's not requested again
+		Evaluate(`import('/chunk-settings.js').then(function() { return window.settingsLoaded; })`, &loaded, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded {
+		t.Error("expected the chunk to have been evaluated")
+	}
+}
+
+func TestWaitJSONResponse(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<script>
+	setTimeout(function() {
+		fetch('/api/user');
+		fetch('/api/broken');
+	}, 200);
+</script>`))
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"gopher","roles":["admin"]}`))
+	})
+	mux.HandleFunc("/api/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var user struct {
+		Name  string   `json:"name"`
+		Roles []string `json:"roles"`
+	}
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitJSONResponse(regexp.MustCompile(`/api/user$`), &user),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "gopher" || len(user.Roles) != 1 || user.Roles[0] != "admin" {
+		t.Errorf("expected the user payload, got: %+v", user)
+	}
+
+	if err := Run(ctx, WaitJSONResponse(regexp.MustCompile(`/api/broken$`), &user)); err == nil {
+		t.Error("expected an error for an invalid JSON body")
+	}
+}
+
+func TestCaptureBeacons(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`
+<button id="buy" onclick="navigator.sendBeacon('/collect', 'event=buy')">buy</button>
+<a id="next" href="#next" ping="/ping">next</a>
+	`))
+	mux.HandleFunc("/collect", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var calls []BeaconCall
+	if err := Run(ctx,
+		CaptureBeacons(&calls),
+		Navigate(s.URL),
+		Click("#buy", ByQuery),
+		Click("#next", ByQuery),
+		// the bindings are called before the evaluation returns
+		Evaluate(`true`, &[]byte{}),
+	); err != nil {
+		t.Fatal(err)
+	}
+	want := []BeaconCall{
+		{Type: "beacon", URL: s.URL + "/collect", Payload: "event=buy"},
+		{Type: "ping", URL: s.URL + "/ping"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected beacons %+v, got: %+v", want, calls)
+	}
+}
+
+func TestSetAcceptHeader(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<div id="out"></div>`))
+	mux.HandleFunc("/negotiate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "application/json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"format":"json"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p>html</p>`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body string
+	if err := Run(ctx,
+		SetAcceptHeader(regexp.MustCompile(`/negotiate$`), "application/json"),
+		Navigate(s.URL),
+		Evaluate(`fetch('/negotiate', {headers: {'Accept': 'text/html'}}).then(function(r) { return r.text(); })`, &body, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"format":"json"}`; body != want {
+		t.Errorf("expected body %q, got: %q", want, body)
+	}
+}
+
+func TestSetAcceptEncoding(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>page</p>`))
+	mux.HandleFunc("/compressed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte("compressed"))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte("compressed"))
+		gw.Close()
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	tests := []struct {
+		name, accept, want string
+	}{
+		{"Default", "", "gzip"},
+		{"Identity", "identity", ""},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := testAllocate(t, "")
+			defer cancel()
+
+			urlPattern := regexp.MustCompile(`/compressed$`)
+			var actions Tasks
+			if test.accept != "" {
+				actions = append(actions, SetAcceptEncoding(urlPattern, test.accept))
+			}
+			var body, encoding string
+			actions = append(actions,
+				network.Enable(),
+				Navigate(s.URL),
+				Evaluate(`fetch('/compressed').then(function(r) { return r.text(); })`, &body, evalAwaitPromise),
+				ResponseEncoding(urlPattern, &encoding),
+			)
+			if err := Run(ctx, actions); err != nil {
+				t.Fatal(err)
+			}
+			if body != "compressed" {
+				t.Errorf("expected body %q, got: %q", "compressed", body)
+			}
+			if encoding != test.want {
+				t.Errorf("expected encoding %q, got: %q", test.want, encoding)
+			}
+		})
+	}
+}
+
+func TestSetOrigin(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>page</p>`))
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.Header.Get("Origin")))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const origin = "https://example.com"
+	var body string
+	if err := Run(ctx,
+		SetOrigin(regexp.MustCompile(`/api$`), origin),
+		Navigate(s.URL),
+		Evaluate(`fetch('/api').then(function(r) { return r.text(); })`, &body, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if body != origin {
+		t.Errorf("expected origin %q, got: %q", origin, body)
+	}
+}
+
+func TestResponseSetCookies(t *testing.T) {
+	t.Parallel()
+
+	want := []string{
+		"response_session=abc; Path=/; HttpOnly; SameSite=Strict",
+		"response_prefs=dark; Max-Age=3600",
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>page</p>`))
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		for _, cookie := range want {
+			w.Header().Add("Set-Cookie", cookie)
+		}
+		w.Write([]byte("ok"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body string
+	var cookies []string
+	if err := Run(ctx,
+		network.Enable(),
+		Navigate(s.URL),
+		Evaluate(`fetch('/login').then(function(r) { return r.text(); })`, &body, evalAwaitPromise),
+		ResponseSetCookies(regexp.MustCompile(`/login$`), &cookies),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cookies, want) {
+		t.Errorf("expected cookies %q, got: %q", want, cookies)
+	}
+}
+
+func TestInterceptCombined(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>page</p>`))
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.Header.Get("Origin")))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(bytes.Repeat([]byte("x"), 2000))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const origin = "https://example.com"
+	const fetchAPI = `fetch('/api').then(function(r) { return r.text(); })`
+	const timeSlow = `(function() {
+		var start = performance.now();
+		return fetch('/slow').then(function(r) { return r.text(); }).then(function() {
+			return performance.now() - start;
+		});
+	})()`
+	var body string
+	var elapsed float64
+	// the request and response stage interceptions don't clobber each other
+	if err := Run(ctx,
+		SetOrigin(regexp.MustCompile(`/api$`), origin),
+		ThrottleRequest(regexp.MustCompile(`/slow$`), 4000),
+		Navigate(s.URL),
+		Evaluate(fetchAPI, &body, evalAwaitPromise),
+		Evaluate(timeSlow, &elapsed, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if body != origin {
+		t.Errorf("expected origin %q, got: %q", origin, body)
+	}
+	if elapsed < 400 {
+		t.Errorf("expected the response to be throttled, took %fms", elapsed)
+	}
+
+	// cancelling the context of an interception removes it, releasing its
+	// held requests, and leaves the others in place
+	delayCtx, delayCancel := context.WithCancel(ctx)
+	if err := Run(delayCtx, DelayRequests(regexp.MustCompile(`/api$`), time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(200*time.Millisecond, delayCancel)
+	body = ""
+	start := time.Now()
+	if err := Run(ctx, Evaluate(fetchAPI, &body, evalAwaitPromise)); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > 10*time.Second {
+		t.Errorf("expected the held request to be released, took %v", took)
+	}
+	body = ""
+	if err := Run(ctx, Evaluate(fetchAPI, &body, evalAwaitPromise)); err != nil {
+		t.Fatal(err)
+	}
+	if body != origin {
+		t.Errorf("expected origin %q once the delay is removed, got: %q", origin, body)
+	}
+
+	// two actions fulfilling the same requests can't be combined
+	mock := func(string) string { return "page.html" }
+	if err := Run(ctx, MockFromDir("testdata", mock)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx, MockFromDir("testdata", mock)); err == nil {
+		t.Error("expected an error when fulfilling the requests twice")
+	}
+}
+
+func TestSetDynamicHeaders(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("secret")
+	sign := func(urlstr string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(urlstr))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>page</p>`))
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		valid := r.Header.Get("X-Signature") == sign("http://"+r.Host+r.URL.String())
+		fmt.Fprintf(w, "%t %s %s", valid, r.Header.Get("X-Client"), r.Header.Get("X-Extra"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body string
+	if err := Run(ctx,
+		SetDynamicHeaders(func(req *fetch.EventRequestPaused) network.Headers {
+			if !strings.Contains(req.Request.URL, "/api") {
+				return nil
+			}
+			buf, _ := json.Marshal(map[string]string{
+				"X-Signature": sign(req.Request.URL),
+				"x-client":    "chromedp",
+			})
+			return network.Headers(buf)
+		}),
+		Navigate(s.URL),
+		Evaluate(`fetch('/api?id=1', {headers: {'X-Client': 'page', 'X-Extra': 'kept'}}).then(function(r) { return r.text(); })`, &body, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "true chromedp kept"; body != want {
+		t.Errorf("expected body %q, got: %q", want, body)
+	}
+}
+
+func TestSetDynamicHeadersCombined(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>page</p>`))
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Header.Get("Accept"), r.Header.Get("X-Signature"))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var body string
+	if err := Run(ctx,
+		SetAcceptHeader(regexp.MustCompile(`/api$`), "application/json"),
+		SetDynamicHeaders(func(req *fetch.EventRequestPaused) network.Headers {
+			return network.Headers(`{"X-Signature":"signed"}`)
+		}),
+		Navigate(s.URL),
+		Evaluate(`fetch('/api').then(function(r) { return r.text(); })`, &body, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "application/json signed"; body != want {
+		t.Errorf("expected both headers in %q, got: %q", want, body)
+	}
+}
+
+func TestDelayRequests(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>delay</p>`))
+	for _, name := range []string{"/slow", "/fast"} {
+		mux.HandleFunc(name, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`ok`))
+		})
+	}
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const delay = 500 * time.Millisecond
+	const elapsed = `function elapsed(path) {
+		var start = performance.now();
+		return fetch(path).then(function() { return performance.now() - start; });
+	}`
+	var slow, fast float64
+	if err := Run(ctx,
+		DelayRequests(regexp.MustCompile(`/slow$`), delay),
+		Navigate(s.URL),
+		Evaluate(`(`+elapsed+`)('/slow')`, &slow, evalAwaitPromise),
+		Evaluate(`(`+elapsed+`)('/fast')`, &fast, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if slow < float64(delay/time.Millisecond) {
+		t.Errorf("expected /slow to take at least %v, got: %vms", delay, slow)
+	}
+	if fast >= float64(delay/time.Millisecond) {
+		t.Errorf("expected /fast not to be delayed, got: %vms", fast)
+	}
+}
+
+func TestThrottleRequest(t *testing.T) {
+	t.Parallel()
+
+	asset := strings.Repeat("x", 2000)
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>throttle</p>`))
+	for _, name := range []string{"/slow.js", "/fast.js"} {
+		mux.HandleFunc(name, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte(asset))
+		})
+	}
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const elapsed = `function elapsed(path) {
+		var start = performance.now();
+		return fetch(path).then(function(r) { return r.text(); }).then(function(body) {
+			return [body.length, performance.now() - start];
+		});
+	}`
+	var slow, fast []float64
+	if err := Run(ctx,
+		// 2000 bytes at 4000 bytes per second take 500ms
+		ThrottleRequest(regexp.MustCompile(`/slow\.js$`), 4000),
+		Navigate(s.URL),
+		Evaluate(`(`+elapsed+`)('/slow.js')`, &slow, evalAwaitPromise),
+		Evaluate(`(`+elapsed+`)('/fast.js')`, &fast, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if slow[0] != float64(len(asset)) || slow[1] < 500 {
+		t.Errorf("expected /slow.js to take at least 500ms, got: %v", slow)
+	}
+	if fast[0] != float64(len(asset)) || fast[1] >= 500 {
+		t.Errorf("expected /fast.js not to be throttled, got: %v", fast)
+	}
+}
+
+func TestOverrideEncoding(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// latin-1 encoded content, declared as utf-8
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<p id=\"text\">caf\xe9</p>"))
+	}))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Encoding(&before),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "UTF-8"; before != want {
+		t.Errorf("expected encoding %q, got: %q", want, before)
+	}
+
+	var after, text string
+	if err := Run(ctx,
+		OverrideEncoding(regexp.MustCompile(`.*`), "windows-1252"),
+		Navigate(s.URL),
+		Encoding(&after),
+		Text(`#text`, &text, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "windows-1252"; after != want {
+		t.Errorf("expected encoding %q, got: %q", want, after)
+	}
+	if want := "café"; text != want {
+		t.Errorf("expected text %q, got: %q", want, text)
+	}
+}
+
+func TestAssertNoRequest(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<script>
+	setTimeout(function() {
+		var img = new Image();
+		img.src = '/tracker.gif';
+		var s = document.createElement('script');
+		s.src = '/app.js';
+		document.head.appendChild(s);
+	}, 200);
+</script>`))
+	mux.HandleFunc("/app.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+	})
+	mux.HandleFunc("/tracker.gif", func(w http.ResponseWriter, r *http.Request) {})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	tracker := regexp.MustCompile(`/tracker\.gif$`)
+	err := Run(ctx,
+		Navigate(s.URL),
+		AssertNoRequest(tracker, time.Second),
+	)
+	if want := fmt.Sprintf("unexpected request to %q", s.URL+"/tracker.gif"); err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got: %v", want, err)
+	}
+
+	// The tracker was already requested, and isn't requested again.
+	if err := Run(ctx, AssertNoRequest(tracker, 500*time.Millisecond)); err != nil {
+		t.Errorf("expected no further tracker request, got: %v", err)
+	}
+}
+
+func TestTargetNetworkEvents(t *testing.T) {
+	t.Parallel()
+
+	tgt := &Target{requests: make(map[network.RequestID]*trackedRequest)}
+	send := func(id network.RequestID) {
+		tgt.networkEvent(&network.EventRequestWillBeSent{RequestID: id, Request: &network.Request{}})
+	}
+	receive := func(id network.RequestID, urlstr string) {
+		tgt.networkEvent(&network.EventResponseReceived{RequestID: id, Response: &network.Response{URL: urlstr}})
+	}
+	byURL := func(urlstr string) func(*trackedRequest) bool {
+		return func(req *trackedRequest) bool { return req.res.Response.URL == urlstr }
+	}
+
+	// concurrent requests for the same URL are kept apart
+	send("1")
+	send("2")
+	receive("1", "/api")
+	receive("2", "/api")
+	tgt.networkEvent(&network.EventResponseReceivedExtraInfo{RequestID: "1", Headers: network.Headers(`{"a":"1"}`)})
+	tgt.networkEvent(&network.EventLoadingFinished{RequestID: "1"})
+	if got := tgt.inflightRequests(); got != 1 {
+		t.Errorf("expected 1 request in flight, got: %d", got)
+	}
+	if req := tgt.lastResponse(byURL("/api")); req == nil || req.res.RequestID != "2" || req.loaded {
+		t.Errorf("expected the last response to be request 2 still loading, got: %+v", req)
+	}
+	req := tgt.lastResponse(func(req *trackedRequest) bool { return req.loaded })
+	if req == nil || req.res.RequestID != "1" || string(req.rawHeaders) != `{"a":"1"}` {
+		t.Errorf("expected request 1 to be loaded with its raw headers, got: %+v", req)
+	}
+
+	// failed and cancelled requests are no longer in flight
+	tgt.networkEvent(&network.EventLoadingFailed{RequestID: "2", Canceled: true})
+	if got := tgt.inflightRequests(); got != 0 {
+		t.Errorf("expected no request in flight, got: %d", got)
+	}
+
+	// old responses are evicted
+	for i := 0; i < maxResponses+10; i++ {
+		id := network.RequestID(fmt.Sprintf("r%d", i))
+		send(id)
+		receive(id, fmt.Sprintf("/r%d", i))
+		tgt.networkEvent(&network.EventLoadingFinished{RequestID: id})
+	}
+	if got := len(tgt.responses); got != maxResponses {
+		t.Errorf("expected %d responses to be kept, got: %d", maxResponses, got)
+	}
+	if req := tgt.lastResponse(byURL("/r0")); req != nil {
+		t.Errorf("expected the oldest response to be evicted, got: %+v", req)
+	}
+	if req := tgt.lastResponse(byURL(fmt.Sprintf("/r%d", maxResponses+9))); req == nil {
+		t.Error("expected the newest response to be kept")
+	}
+}
+
+func TestPausedRequestHeaders(t *testing.T) {
+	t.Parallel()
+
+	headerMap := func(headers []*fetch.HeaderEntry) map[string]string {
+		m := make(map[string]string)
+		for _, h := range headers {
+			m[h.Name] = h.Value
+		}
+		return m
+	}
+
+	// the rewrites of several handlers are merged
+	req := &pausedRequest{EventRequestPaused: &fetch.EventRequestPaused{
+		Request: &network.Request{Headers: network.Headers(`{"Accept":"text/html","X-Kept":"1"}`)},
+	}}
+	req.setHeader("accept", "application/json")
+	req.setHeader("Origin", "https://example.com")
+	want := map[string]string{"accept": "application/json", "Origin": "https://example.com", "X-Kept": "1"}
+	if got := headerMap(req.headers); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected request headers %v, got: %v", want, got)
+	}
+
+	// removing a missing header leaves the response unmodified
+	res := &pausedRequest{EventRequestPaused: &fetch.EventRequestPaused{
+		ResponseStatusCode: 200,
+		ResponseHeaders:    []*fetch.HeaderEntry{{Name: "Content-Type", Value: "text/html"}},
+	}}
+	res.delHeader("Set-Cookie")
+	if res.headers != nil {
+		t.Errorf("expected the response headers to be unmodified, got: %v", headerMap(res.headers))
+	}
+	overrideCharset(res, "shift_jis")
+	if v, _ := res.header("content-type"); v != "text/html; charset=shift_jis" {
+		t.Errorf("expected the charset to be overridden, got: %q", v)
+	}
+	if len(res.ResponseHeaders) != 1 || res.ResponseHeaders[0].Value != "text/html" {
+		t.Errorf("expected the original response headers to be kept, got: %v", headerMap(res.ResponseHeaders))
+	}
+}
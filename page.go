@@ -0,0 +1,428 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+)
+
+// ContentHeight is an action that retrieves the scroll height of the current
+// page's document element, that is the height of its whole content, including
+// the content not visible due to overflow.
+func ContentHeight(h *float64) Action {
+	if h == nil {
+		panic("h cannot be nil")
+	}
+	return Evaluate(`document.documentElement.scrollHeight`, h)
+}
+
+// ViewportMetrics is an action that retrieves the layout metrics of the
+// current page: the layout viewport, the visual viewport, which differs from
+// the layout viewport when the page is pinch-zoomed, and the size of the
+// scrollable content, all in CSS pixels.
+//
+// Useful for computing the clip of a screenshot, taking the scale of the
+// visual viewport into account.
+func ViewportMetrics(metrics *page.GetLayoutMetricsReturns) Action {
+	if metrics == nil {
+		panic("metrics cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		layout, visual, content, err := page.GetLayoutMetrics().Do(ctx)
+		if err != nil {
+			return err
+		}
+		*metrics = page.GetLayoutMetricsReturns{
+			LayoutViewport: layout,
+			VisualViewport: visual,
+			ContentSize:    content,
+		}
+		return nil
+	})
+}
+
+// WaitContentStable is an action that waits until the scroll height of the
+// current page's document element hasn't changed for the quiet duration.
+//
+// Useful for knowing when the content loaded by an infinite scroll has
+// settled, or, with a timeout on the context, for detecting pages which keep
+// inserting content.
+func WaitContentStable(quiet time.Duration) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		var last float64
+		var stableSince time.Time
+		return waitFor(ctx, 50*time.Millisecond, func(ctx context.Context) (bool, error) {
+			var h float64
+			if err := ContentHeight(&h).Do(ctx); err != nil {
+				return false, err
+			}
+			if stableSince.IsZero() || h != last {
+				last, stableSince = h, time.Now()
+				return false, nil
+			}
+			return time.Since(stableSince) >= quiet, nil
+		})
+	})
+}
+
+// Links is an action that retrieves the href values of all the anchor (<a>)
+// elements in the document, in document order.
+//
+// By default, the href attribute values are returned as written in the
+// document. See LinksAbsolute and LinksSameOrigin to change this behavior.
+func Links(links *[]string, opts ...LinkOption) Action {
+	if links == nil {
+		panic("links cannot be nil")
+	}
+
+	o := new(linkOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return EvaluateAsDevTools(fmt.Sprintf(linksJS, o.absolute, o.sameOrigin), links)
+}
+
+type linkOptions struct {
+	absolute   bool
+	sameOrigin bool
+}
+
+// LinkOption is a Links action option.
+type LinkOption = func(*linkOptions)
+
+// LinksAbsolute is a Links action option to resolve the retrieved links to
+// absolute URLs, using the document's base URL.
+func LinksAbsolute(o *linkOptions) {
+	o.absolute = true
+}
+
+// LinksSameOrigin is a Links action option to only retrieve the links that
+// have the same origin as the document.
+func LinksSameOrigin(o *linkOptions) {
+	o.sameOrigin = true
+}
+
+// Encoding is an action that retrieves the character encoding used to decode
+// the current document, such as "UTF-8" or "windows-1252".
+//
+// See OverrideEncoding to force the encoding of documents that declare the
+// wrong one.
+func Encoding(charset *string) Action {
+	if charset == nil {
+		panic("charset cannot be nil")
+	}
+	return EvaluateAsDevTools(`document.characterSet`, charset)
+}
+
+// MetaTags is an action that retrieves the content of the document's meta
+// elements, keyed by their name, property (eg, for Open Graph tags) or
+// http-equiv attribute. When several elements share a key, the last one wins.
+func MetaTags(tags *map[string]string) Action {
+	if tags == nil {
+		panic("tags cannot be nil")
+	}
+	return EvaluateAsDevTools(metaTagsJS, tags)
+}
+
+// JSONLD is an action that retrieves the structured data blocks of the
+// document, as embedded in <script type="application/ld+json"> elements.
+// An error is returned if any block isn't valid JSON.
+func JSONLD(blocks *[]json.RawMessage) Action {
+	if blocks == nil {
+		panic("blocks cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var texts []string
+		if err := EvaluateAsDevTools(jsonLDJS, &texts).Do(ctx); err != nil {
+			return err
+		}
+		res := make([]json.RawMessage, len(texts))
+		for i, text := range texts {
+			text = strings.TrimSpace(text)
+			if !json.Valid([]byte(text)) {
+				return fmt.Errorf("JSON-LD block %d is not valid JSON", i)
+			}
+			res[i] = json.RawMessage(text)
+		}
+		*blocks = res
+		return nil
+	})
+}
+
+// Favicon is an action that retrieves the content of the document's favicon,
+// as declared by its first <link rel="icon"> element, or /favicon.ico when
+// there's none.
+//
+// The icon is fetched from within the page, so the page's cookies are sent
+// along; as such, icons from other origins must allow it via CORS.
+func Favicon(icon *[]byte) Action {
+	if icon == nil {
+		panic("icon cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var s string
+		if err := Evaluate(faviconJS, &s, evalAwaitPromise).Do(ctx); err != nil {
+			return err
+		}
+		buf, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		*icon = buf
+		return nil
+	})
+}
+
+// InlineHTML is an action that serializes the current document as a single,
+// self-contained HTML document, storing it in out.
+//
+// The document's stylesheets, images, and the resources referenced from its
+// CSS, such as fonts and background images, are fetched from within the page
+// and inlined as data URLs; the current values of form fields are kept, and
+// scripts are removed, so that the document renders as it currently is. Useful
+// to archive pages in a more portable form than MHTML, such as to diff them.
+//
+// Resources are fetched with the page's cookies, so resources from other
+// origins must allow it via CORS; the ones which can't be fetched are left as
+// absolute URLs.
+func InlineHTML(out *string) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return Evaluate(inlineHTMLJS, out, evalAwaitPromise)
+}
+
+// TextBox is an action that retrieves the bounding rectangle of the first
+// visible occurrence of text in the document, relative to the viewport.
+//
+// Useful for locating specific words in a screenshot taken with
+// CaptureScreenshot. Note that text spanning multiple text nodes, such as
+// "foo bar" in "foo <b>bar</b>", is not matched.
+func TextBox(text string, rect *dom.Rect) Action {
+	if rect == nil {
+		panic("rect cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		buf, err := json.Marshal(text)
+		if err != nil {
+			return err
+		}
+
+		var res *dom.Rect
+		if err := Evaluate(fmt.Sprintf(textBoxJS, buf), &res).Do(ctx); err != nil {
+			return err
+		}
+		if res == nil {
+			return fmt.Errorf("no visible text matching %q", text)
+		}
+		*rect = *res
+		return nil
+	})
+}
+
+// IFrameInfo describes a frame embedded in the current page, combining the
+// frame tree with the attributes of the frame's owner element.
+type IFrameInfo struct {
+	FrameID  cdp.FrameID
+	ParentID cdp.FrameID
+
+	// URL is the URL of the frame's document, which may differ from Src
+	// once the frame has navigated.
+	URL string
+
+	// Src is the src attribute of the owner element.
+	Src string
+
+	// Sandboxed is whether the owner element has a sandbox attribute, and
+	// Sandbox is the list of restrictions lifted by it, such as
+	// "allow-scripts". An empty list lifts no restriction.
+	Sandboxed bool
+	Sandbox   []string
+
+	// CrossOrigin is whether the frame's document has a different origin
+	// than the page, which includes the opaque origins of sandboxed frames.
+	CrossOrigin bool
+}
+
+// IFrames is an action that retrieves the frames embedded in the current
+// page, at any depth, in depth-first order.
+//
+// Useful for auditing the third-party content embedded in a page, and the
+// restrictions applied to it.
+//
+// Note: out-of-process iframes belong to separate targets, and are not listed.
+func IFrames(frames *[]IFrameInfo) Action {
+	if frames == nil {
+		panic("frames cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		var infos []IFrameInfo
+		for _, f := range frameTreeFrames(tree)[1:] {
+			info := IFrameInfo{
+				FrameID:     f.ID,
+				ParentID:    f.ParentID,
+				URL:         f.URL,
+				CrossOrigin: f.SecurityOrigin != tree.Frame.SecurityOrigin,
+			}
+
+			// get the frame owner node in the parent document
+			backendNodeID, _, err := dom.GetFrameOwner(f.ID).Do(ctx)
+			if err != nil {
+				// the frame may have been detached
+				continue
+			}
+			owner, err := dom.DescribeNode().WithBackendNodeID(backendNodeID).Do(ctx)
+			if err != nil {
+				return err
+			}
+			for i := 0; i+1 < len(owner.Attributes); i += 2 {
+				switch name, value := owner.Attributes[i], owner.Attributes[i+1]; name {
+				case "src":
+					info.Src = value
+				case "sandbox":
+					info.Sandboxed = true
+					info.Sandbox = strings.Fields(value)
+				}
+			}
+			infos = append(infos, info)
+		}
+		*frames = infos
+		return nil
+	})
+}
+
+// ScreenshotFrame is an action that captures a screenshot of the content region
+// of the frame with the specified ID, as rendered in its parent document.
+//
+// The region is computed from the content box of the frame's owner element
+// (ie, the iframe or frame element), so the owner's border and padding are not
+// included in the screenshot.
+//
+// See CaptureScreenshot for capturing a screenshot of the browser viewport.
+func ScreenshotFrame(frameID cdp.FrameID, res *[]byte) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		// get the frame owner node in the parent document
+		backendNodeID, _, err := dom.GetFrameOwner(frameID).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		// get box model
+		box, err := dom.GetBoxModel().WithBackendNodeID(backendNodeID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if len(box.Content) != 8 {
+			return ErrInvalidBoxModel
+		}
+
+		// take screenshot of the frame's content box
+		*res, err = page.CaptureScreenshot().
+			WithFormat(page.CaptureScreenshotFormatPng).
+			WithClip(&page.Viewport{
+				X:      math.Round(box.Content[0]),
+				Y:      math.Round(box.Content[1]),
+				Width:  math.Round(box.Content[4] - box.Content[0]),
+				Height: math.Round(box.Content[5] - box.Content[1]),
+				Scale:  1.0,
+			}).Do(ctx)
+		return err
+	})
+}
+
+// PrintToPDFPages is an action that prints the current page to PDF, storing
+// each printed page in the page ranges as a separate, single page PDF document
+// in res, such as to rasterize each page as a thumbnail.
+//
+// The page ranges are in the same format as the pageRanges parameter of
+// page.PrintToPDF, such as "1-5, 8, 11-13"; pages are numbered from 1, and
+// open ranges, such as "3-", extend to the last page. An empty string prints
+// all the pages.
+func PrintToPDFPages(res *[][]byte, pageRanges string) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		ranges, err := parsePageRanges(pageRanges)
+		if err != nil {
+			return err
+		}
+
+		var pages [][]byte
+		for _, r := range ranges {
+			for n := r[0]; r[1] == 0 || n <= r[1]; n++ {
+				buf, _, err := page.PrintToPDF().WithPageRanges(strconv.Itoa(n)).Do(ctx)
+				if _, ok := err.(*cdproto.Error); ok && r[1] == 0 && n > r[0] {
+					// the open range went past the last page
+					break
+				}
+				if err != nil {
+					return err
+				}
+				pages = append(pages, buf)
+			}
+		}
+		*res = pages
+		return nil
+	})
+}
+
+// parsePageRanges parses the comma separated page ranges, returning the first
+// and last page of each range. The last page is 0 for open ranges.
+func parsePageRanges(pageRanges string) ([][2]int, error) {
+	if strings.TrimSpace(pageRanges) == "" {
+		return [][2]int{{1, 0}}, nil
+	}
+
+	var ranges [][2]int
+	for _, s := range strings.Split(pageRanges, ",") {
+		s = strings.TrimSpace(s)
+		first, last := s, s
+		if i := strings.IndexByte(s, '-'); i >= 0 {
+			first, last = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+		}
+
+		var r [2]int
+		var err error
+		switch {
+		case first == "":
+			r[0] = 1
+		default:
+			r[0], err = strconv.Atoi(first)
+		}
+		if err == nil && last != "" {
+			r[1], err = strconv.Atoi(last)
+		}
+		if err != nil || r[0] < 1 || (last != "" && r[1] < r[0]) {
+			return nil, fmt.Errorf("invalid page range %q", s)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
@@ -0,0 +1,435 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestMetaTagsAndJSONLD(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<html><head>
+<meta charset="utf-8">
+<meta name="description" content="a test page">
+<meta property="og:title" content="Test">
+<script type="application/ld+json">
+	{"@context": "https://schema.org", "@type": "Organization", "name": "chromedp"}
+</script>
+</head><body></body></html>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var tags map[string]string
+	var blocks []json.RawMessage
+	if err := Run(ctx,
+		Navigate(s.URL),
+		MetaTags(&tags),
+		JSONLD(&blocks),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"description": "a test page", "og:title": "Test"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected meta tags %v, got: %v", want, tags)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 JSON-LD block, got: %d", len(blocks))
+	}
+	var org struct {
+		Type string `json:"@type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(blocks[0], &org); err != nil {
+		t.Fatal(err)
+	}
+	if org.Type != "Organization" || org.Name != "chromedp" {
+		t.Errorf("unexpected structured data: %s", blocks[0])
+	}
+}
+
+func TestWaitContentStable(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<script>
+	var added = 0;
+	var timer = setInterval(function() {
+		var el = document.createElement('div');
+		el.style.height = '1000px';
+		document.body.appendChild(el);
+		if (++added == 5) {
+			clearInterval(timer);
+		}
+	}, 50);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var h float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitContentStable(300*time.Millisecond),
+		ContentHeight(&h),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if h < 5000 {
+		t.Errorf("expected the content to have settled at 5000px or more, got: %f", h)
+	}
+}
+
+func TestViewportMetrics(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<div style="width: 3000px; height: 4000px"></div>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var metrics page.GetLayoutMetricsReturns
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Evaluate(`window.scrollTo(100, 200)`, &[]byte{}),
+		ViewportMetrics(&metrics),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if l := metrics.LayoutViewport; l.PageX != 100 || l.PageY != 200 {
+		t.Errorf("expected the layout viewport at (100, 200), got: (%d, %d)", l.PageX, l.PageY)
+	}
+	if v := metrics.VisualViewport; v.Scale != 1 {
+		t.Errorf("expected a visual viewport scale of 1, got: %f", v.Scale)
+	}
+	if c := metrics.ContentSize; c.Width < 3000 || c.Height < 4000 {
+		t.Errorf("expected a content size of at least 3000x4000, got: %fx%f", c.Width, c.Height)
+	}
+}
+
+func TestIFrames(t *testing.T) {
+	t.Parallel()
+
+	other := httptest.NewServer(writeHTML(`<p>other</p>`))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/child", writeHTML(`<p>child</p>`))
+	mux.Handle("/", writeHTML(fmt.Sprintf(`
+<iframe src="/child"></iframe>
+<iframe src="%s/"></iframe>
+<iframe src="/child" sandbox="allow-scripts allow-forms"></iframe>`, other.URL)))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var frames []IFrameInfo
+	if err := Run(ctx,
+		Navigate(s.URL),
+		IFrames(&frames),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("expected 3 frames, got: %d", len(frames))
+	}
+	tests := []struct {
+		src         string
+		sandbox     []string
+		crossOrigin bool
+	}{
+		{"/child", nil, false},
+		{other.URL + "/", nil, true},
+		{"/child", []string{"allow-scripts", "allow-forms"}, true},
+	}
+	for i, test := range tests {
+		f := frames[i]
+		if f.Src != test.src {
+			t.Errorf("frame %d: expected src %q, got: %q", i, test.src, f.Src)
+		}
+		if f.Sandboxed != (test.sandbox != nil) || !reflect.DeepEqual(f.Sandbox, test.sandbox) {
+			t.Errorf("frame %d: expected sandbox %q, got: %q", i, test.sandbox, f.Sandbox)
+		}
+		if f.CrossOrigin != test.crossOrigin {
+			t.Errorf("frame %d: expected cross-origin %t, got: %t", i, test.crossOrigin, f.CrossOrigin)
+		}
+	}
+}
+
+func TestTextBox(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<p style="display: none">hidden word</p>
+<p>some <b id="word">word</b> in a sentence</p>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var rect dom.Rect
+	var want map[string]float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		TextBox("word", &rect),
+		Evaluate(`(function() {
+			var r = document.getElementById('word').getBoundingClientRect();
+			return {x: r.x, y: r.y, width: r.width, height: r.height};
+		})()`, &want),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if rect.X != want["x"] || rect.Y != want["y"] || rect.Width != want["width"] || rect.Height != want["height"] {
+		t.Errorf("expected rect %v, got: %+v", want, rect)
+	}
+
+	if err := Run(ctx, TextBox("missing", &rect)); err == nil {
+		t.Error("expected an error for missing text")
+	}
+}
+
+func TestScreenshotFrame(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "iframe.html")
+	defer cancel()
+
+	var buf []byte
+	if err := Run(ctx, ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		if len(tree.ChildFrames) != 1 {
+			return fmt.Errorf("expected one child frame, got %d", len(tree.ChildFrames))
+		}
+		return ScreenshotFrame(tree.ChildFrames[0].Frame.ID, &buf).Do(ctx)
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "png"; format != want {
+		t.Fatalf("expected format to be %q, got %q", want, format)
+	}
+	// 300x150 is the default iframe size.
+	if config.Width != 300 || config.Height != 150 {
+		t.Fatalf("expected dimensions to be 300*150, got %d*%d", config.Width, config.Height)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<a href="/foo">foo</a>
+<a href="bar?x=1">bar</a>
+<a>no href</a>
+<a href="https://example.com/baz">baz</a>
+	`))
+	defer s.Close()
+
+	tests := []struct {
+		opts []LinkOption
+		want []string
+	}{
+		{nil, []string{"/foo", "bar?x=1", "https://example.com/baz"}},
+		{[]LinkOption{LinksAbsolute}, []string{s.URL + "/foo", s.URL + "/bar?x=1", "https://example.com/baz"}},
+		{[]LinkOption{LinksSameOrigin}, []string{"/foo", "bar?x=1"}},
+		{[]LinkOption{LinksAbsolute, LinksSameOrigin}, []string{s.URL + "/foo", s.URL + "/bar?x=1"}},
+	}
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		var links []string
+		if err := Run(ctx, Links(&links, test.opts...)); err != nil {
+			t.Fatalf("test %d got error: %v", i, err)
+		}
+		if !reflect.DeepEqual(links, test.want) {
+			t.Errorf("test %d expected %q, got %q", i, test.want, links)
+		}
+	}
+}
+
+func TestFavicon(t *testing.T) {
+	t.Parallel()
+
+	icon, err := ioutil.ReadFile("testdata/images/github.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<p>no icon link</p>`))
+	mux.Handle("/linked", writeHTML(`<head><link rel="shortcut icon" href="icons/brand.png"></head>`))
+	serveIcon := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(icon)
+	}
+	mux.HandleFunc("/icons/brand.png", serveIcon)
+	mux.HandleFunc("/favicon.ico", serveIcon)
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	for _, path := range []string{"/linked", "/"} {
+		var res []byte
+		if err := Run(ctx,
+			Navigate(s.URL+path),
+			Evaluate(`document.cookie = "session=1"`, &[]byte{}),
+			Favicon(&res),
+		); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !bytes.Equal(res, icon) {
+			t.Errorf("%s: expected the favicon to be %d bytes, got: %d", path, len(icon), len(res))
+		}
+	}
+}
+
+func TestPrintToPDFPages(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<p style="break-after: page">one</p>
+<p style="break-after: page">two</p>
+<p>three</p>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pageRanges string
+		want       int
+	}{
+		{"", 3},
+		{"2-", 2},
+		{"1, 3", 2},
+		{"-2", 2},
+	}
+	for _, test := range tests {
+		var pages [][]byte
+		if err := Run(ctx, PrintToPDFPages(&pages, test.pageRanges)); err != nil {
+			t.Fatalf("page ranges %q: %v", test.pageRanges, err)
+		}
+		if len(pages) != test.want {
+			t.Errorf("page ranges %q: expected %d pages, got: %d", test.pageRanges, test.want, len(pages))
+		}
+		for _, buf := range pages {
+			if !bytes.HasPrefix(buf, []byte("%PDF")) {
+				t.Errorf("page ranges %q: expected a PDF document", test.pageRanges)
+			}
+		}
+	}
+
+	var pages [][]byte
+	for _, pageRanges := range []string{"3-1", "0", "a-b", "4-"} {
+		if err := Run(ctx, PrintToPDFPages(&pages, pageRanges)); err == nil {
+			t.Errorf("page ranges %q: expected an error", pageRanges)
+		}
+	}
+}
+
+func TestInlineHTML(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<!DOCTYPE html>
+<html>
+<head>
+	<link rel="stylesheet" href="style.css">
+	<style>p { background: url(dot.png); }</style>
+</head>
+<body>
+	<img id="img" src="dot.png">
+	<img id="missing" src="missing.png">
+	<input id="input">
+	<a href="other">other</a>
+	<script>document.getElementById('input').value = 'typed';</script>
+</body>
+</html>`))
+	mux.HandleFunc("/style.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		io.WriteString(w, `@import "more.css" print;
+body { background: url('dot.png'); }`)
+	})
+	mux.HandleFunc("/more.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		io.WriteString(w, `.more { color: red; }`)
+	})
+	mux.HandleFunc("/dot.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, "\x89PNG")
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var out string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		InlineHTML(&out),
+	); err != nil {
+		t.Fatal(err)
+	}
+	dot := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("\x89PNG"))
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<img id="img" src="` + dot + `">`,
+		`<img id="missing" src="` + s.URL + `/missing.png">`,
+		`p { background: url("` + dot + `"); }`,
+		`body { background: url("` + dot + `"); }`,
+		"@media print {\n.more { color: red; }\n}",
+		`<input id="input" value="typed">`,
+		`<a href="` + s.URL + `/other">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the document to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"<script", "<link", "style.css"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected the document not to contain %q, got:\n%s", unwanted, out)
+		}
+	}
+}
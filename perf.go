@@ -0,0 +1,315 @@
+package chromedp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/chromedp/cdproto/heapprofiler"
+	"github.com/chromedp/cdproto/layertree"
+	"github.com/chromedp/cdproto/performance"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// MeasureFPS is an action that runs the during action while counting the
+// animation frames rendered by the current page, storing the average number of
+// frames per second in fps.
+//
+// Useful to benchmark the smoothness of scrolling or animations. Frames are
+// counted with requestAnimationFrame, so the page must stay in the same
+// document while the action runs.
+func MeasureFPS(during Action, fps *float64) Action {
+	if fps == nil {
+		panic("fps cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		const key = "__chromedpFrameCounter"
+		if err := Evaluate(fmt.Sprintf(frameCounterStartJS, key), &[]byte{}).Do(ctx); err != nil {
+			return err
+		}
+		if err := during.Do(ctx); err != nil {
+			return err
+		}
+
+		var res []float64
+		if err := Evaluate(fmt.Sprintf(frameCounterStopJS, key), &res).Do(ctx); err != nil {
+			return err
+		}
+		if res[1] <= 0 {
+			return errors.New("the page navigated while measuring the frame rate")
+		}
+		*fps = res[0] / (res[1] / 1000)
+		return nil
+	})
+}
+
+// MeasureReflows is an action that enables the Performance domain, and then
+// runs the during action, storing the number of layouts the current page did
+// in the meantime in count.
+//
+// Useful to detect layout thrashing, such as a handler which forces a
+// synchronous layout on every iteration of a loop by reading the geometry of
+// the elements it modifies. The count is the difference of the LayoutCount
+// metric, so it includes the layouts done as part of rendering the frames, in
+// addition to the forced ones.
+func MeasureReflows(during Action, count *int) Action {
+	if count == nil {
+		panic("count cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := performance.Enable().Do(ctx); err != nil {
+			return err
+		}
+		before, err := layoutCount(ctx)
+		if err != nil {
+			return err
+		}
+		if err := during.Do(ctx); err != nil {
+			return err
+		}
+		after, err := layoutCount(ctx)
+		if err != nil {
+			return err
+		}
+		*count = int(after - before)
+		return nil
+	})
+}
+
+// layoutCount returns the LayoutCount metric of the current page.
+func layoutCount(ctx context.Context) (float64, error) {
+	metrics, err := performance.GetMetrics().Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range metrics {
+		if m.Name == "LayoutCount" {
+			return m.Value, nil
+		}
+	}
+	return 0, errors.New("the LayoutCount metric is not available")
+}
+
+// LayerTree is an action that enables the LayerTree domain, and then retrieves
+// the compositor layers of the current page. The domain is left enabled, as
+// the layer IDs are only valid while it is, so that the layers can be further
+// inspected with LayerCompositingReasons and ProfileLayer.
+func LayerTree(layers *[]*layertree.Layer) Action {
+	if layers == nil {
+		panic("layers cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var res []*layertree.Layer
+		expect, release := expectEvent(ctx, func(ev interface{}) bool {
+			e, ok := ev.(*layertree.EventLayerTreeDidChange)
+			if ok {
+				res = e.Layers
+			}
+			return ok
+		})
+		defer release()
+
+		if err := layertree.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if err := expect(); err != nil {
+			return err
+		}
+		*layers = res
+		return nil
+	})
+}
+
+// LayerCompositingReasons is an action that retrieves the reasons why the
+// layer was composited, as returned by LayerTree.
+func LayerCompositingReasons(layerID layertree.LayerID, reasons *[]string) Action {
+	if reasons == nil {
+		panic("reasons cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var err error
+		*reasons, err = layertree.CompositingReasons(layerID).Do(ctx)
+		return err
+	})
+}
+
+// ProfileLayer is an action that takes a snapshot of the layer, as returned by
+// LayerTree, and replays it repeat times, storing the duration in seconds of
+// each paint step of every run in timings.
+//
+// Useful to find out which layers of a page are expensive to paint.
+func ProfileLayer(layerID layertree.LayerID, repeat int, timings *[]layertree.PaintProfile) Action {
+	if timings == nil {
+		panic("timings cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		id, err := layertree.MakeSnapshot(layerID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer layertree.ReleaseSnapshot(id).Do(ctx)
+
+		*timings, err = layertree.ProfileSnapshot(id).WithMinRepeatCount(int64(repeat)).Do(ctx)
+		return err
+	})
+}
+
+// TakeHeapSnapshot is an action that takes a snapshot of the Javascript heap of
+// the current target, storing it in res in the .heapsnapshot JSON format, which
+// can be loaded in the Memory panel of Chrome DevTools.
+//
+// Useful to diagnose leaks, such as detached DOM trees. Note that snapshots
+// of large heaps take a while, and can be hundreds of megabytes in size.
+func TakeHeapSnapshot(res *[]byte) Action {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var mu sync.Mutex
+		var buf bytes.Buffer
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			if e, ok := ev.(*heapprofiler.EventAddHeapSnapshotChunk); ok {
+				mu.Lock()
+				buf.WriteString(e.Chunk)
+				mu.Unlock()
+			}
+		})
+
+		if err := heapprofiler.Enable().Do(ctx); err != nil {
+			return err
+		}
+		// Events are delivered in order, and every chunk is sent before the
+		// command returns, so the snapshot is complete at that point.
+		if err := heapprofiler.TakeHeapSnapshot().Do(ctx); err != nil {
+			return err
+		}
+		cancel()
+
+		mu.Lock()
+		defer mu.Unlock()
+		*res = buf.Bytes()
+		return nil
+	})
+}
+
+// CollectGarbage is an action that enables the HeapProfiler domain, and then
+// forces a garbage collection of the Javascript heap of the current target.
+//
+// Useful to make memory measurements between steps stable; see HeapUsage.
+func CollectGarbage() Action {
+	return ActionFunc(func(ctx context.Context) error {
+		if err := heapprofiler.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return heapprofiler.CollectGarbage().Do(ctx)
+	})
+}
+
+// HeapStats are the statistics of a Javascript heap, in bytes.
+type HeapStats struct {
+	// Used is the size of the live objects.
+	Used float64
+
+	// Total is the size allocated for the heap.
+	Total float64
+
+	// Limit is the maximum size the heap can grow to. It's zero when the
+	// browser doesn't report it via performance.memory.
+	Limit float64
+}
+
+// HeapUsage is an action that retrieves the statistics of the Javascript heap
+// of the current target.
+//
+// Useful to hunt memory leaks, by sampling the heap size after repeating an
+// interaction. As the garbage collector runs at arbitrary times, use the
+// HeapCollectGarbage option to force a collection before sampling, for the
+// samples to be comparable.
+func HeapUsage(stats *HeapStats, opts ...HeapUsageOption) Action {
+	if stats == nil {
+		panic("stats cannot be nil")
+	}
+
+	o := new(heapUsageOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if o.collectGarbage {
+			if err := CollectGarbage().Do(ctx); err != nil {
+				return err
+			}
+		}
+
+		used, total, err := runtime.GetHeapUsage().Do(ctx)
+		if err != nil {
+			return err
+		}
+		var limit float64
+		if err := Evaluate(heapLimitJS, &limit).Do(ctx); err != nil {
+			return err
+		}
+		*stats = HeapStats{Used: used, Total: total, Limit: limit}
+		return nil
+	})
+}
+
+type heapUsageOptions struct {
+	collectGarbage bool
+}
+
+// HeapUsageOption is a HeapUsage action option.
+type HeapUsageOption = func(*heapUsageOptions)
+
+// HeapCollectGarbage is a HeapUsage action option to force a garbage
+// collection before retrieving the statistics, as CollectGarbage does.
+func HeapCollectGarbage(o *heapUsageOptions) {
+	o.collectGarbage = true
+}
+
+// ResourceTiming is the timing of a resource loaded by a page, as reported by
+// the Resource Timing API.
+type ResourceTiming struct {
+	// Name is the URL of the resource.
+	Name string
+
+	// InitiatorType is the type of the element or API which initiated the
+	// request, such as "script", "img" or "fetch".
+	InitiatorType string
+
+	// StartTime and Duration are the time the request started at, relative
+	// to the navigation, and the time it took to load the resource, both in
+	// milliseconds.
+	StartTime float64
+	Duration  float64
+
+	// TransferSize is the size of the response, including its headers, in
+	// bytes. It's zero for resources served from a cache, and for
+	// cross-origin resources without a Timing-Allow-Origin header.
+	TransferSize float64
+}
+
+// ResourceTimings is an action that retrieves the timings of the resources
+// loaded by the current document, in the order they were requested, via
+// performance.getEntriesByType.
+//
+// Useful to find out which resources, such as third-party scripts, are the
+// slowest to load. Note that the browser buffers a limited number of entries,
+// 250 by default.
+func ResourceTimings(timings *[]ResourceTiming) Action {
+	if timings == nil {
+		panic("timings cannot be nil")
+	}
+	return Evaluate(resourceTimingsJS, timings)
+}
@@ -0,0 +1,216 @@
+package chromedp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/layertree"
+)
+
+func TestMeasureFPS(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var fps float64
+	if err := Run(ctx, MeasureFPS(Sleep(500*time.Millisecond), &fps)); err != nil {
+		t.Fatal(err)
+	}
+	if fps <= 0 || fps > 1000 {
+		t.Errorf("expected a plausible frame rate, got: %f", fps)
+	}
+}
+
+func TestMeasureReflows(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Evaluate(`for (var i = 0; i < 10; i++) {
+		document.body.appendChild(document.createElement('p'));
+	}`, &[]byte{})); err != nil {
+		t.Fatal(err)
+	}
+
+	var batched, thrashed int
+	if err := Run(ctx,
+		MeasureReflows(Evaluate(`var ps = document.querySelectorAll('p');
+		ps.forEach(function(p) { p.style.height = '10px'; });
+		ps.forEach(function(p) { p.offsetHeight; });`, &[]byte{}), &batched),
+		MeasureReflows(Evaluate(`document.querySelectorAll('p').forEach(function(p) {
+			p.style.height = '20px';
+			p.offsetHeight;
+		});`, &[]byte{}), &thrashed),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if batched < 1 || batched > 3 {
+		t.Errorf("expected about one layout when batching, got: %d", batched)
+	}
+	if thrashed < 10 {
+		t.Errorf("expected at least 10 layouts when thrashing, got: %d", thrashed)
+	}
+}
+
+func TestLayerTree(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "image.html")
+	defer cancel()
+
+	var layers []*layertree.Layer
+	if err := Run(ctx, LayerTree(&layers)); err != nil {
+		t.Fatal(err)
+	}
+	var layer *layertree.Layer
+	for _, l := range layers {
+		if l.DrawsContent {
+			layer = l
+			break
+		}
+	}
+	if layer == nil {
+		t.Fatalf("expected a layer which draws content, got: %d layers", len(layers))
+	}
+
+	var reasons []string
+	var timings []layertree.PaintProfile
+	if err := Run(ctx,
+		LayerCompositingReasons(layer.LayerID, &reasons),
+		ProfileLayer(layer.LayerID, 3, &timings),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(timings) < 3 {
+		t.Errorf("expected at least 3 paint profiles, got: %d", len(timings))
+	}
+}
+
+func TestHeapUsage(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, grown, collected HeapStats
+	if err := Run(ctx,
+		HeapUsage(&before, HeapCollectGarbage),
+		Evaluate(`window.leak = []; for (var i = 0; i < 100000; i++) { window.leak.push({i: i}); }`, &[]byte{}),
+		HeapUsage(&grown),
+		Evaluate(`window.leak = null`, &[]byte{}),
+		HeapUsage(&collected, HeapCollectGarbage),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if before.Used <= 0 || before.Total < before.Used || before.Limit < before.Total {
+		t.Errorf("unexpected heap statistics: %+v", before)
+	}
+	if grown.Used <= before.Used {
+		t.Errorf("expected the heap to grow from %v, got: %v", before.Used, grown.Used)
+	}
+	if collected.Used >= grown.Used {
+		t.Errorf("expected the heap to shrink from %v after collecting garbage, got: %v", grown.Used, collected.Used)
+	}
+}
+
+func TestTakeHeapSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var buf []byte
+	if err := Run(ctx,
+		Evaluate(`function ChromedpLeak() {}; window.leaked = new ChromedpLeak()`, &[]byte{}),
+		TakeHeapSnapshot(&buf),
+	); err != nil {
+		t.Fatal(err)
+	}
+	var snapshot struct {
+		Snapshot struct {
+			NodeCount int `json:"node_count"`
+		} `json:"snapshot"`
+		Strings []string `json:"strings"`
+	}
+	if err := json.Unmarshal(buf, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Snapshot.NodeCount == 0 {
+		t.Error("expected the snapshot to contain nodes")
+	}
+	found := false
+	for _, s := range snapshot.Strings {
+		if s == "ChromedpLeak" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("expected the snapshot to contain the leaked object")
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var collected bool
+	if err := Run(ctx,
+		Evaluate(`window.ref = new WeakRef({data: new Array(1000).fill(0)}); true`, &[]byte{}),
+		// WeakRef targets are kept alive until the current task ends.
+		Sleep(10*time.Millisecond),
+		CollectGarbage(),
+		Evaluate(`window.ref.deref() === undefined`, &collected),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !collected {
+		t.Error("expected the unreachable object to be collected")
+	}
+}
+
+func TestResourceTimings(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`<script src="/slow.js"></script><img src="/image.png">`))
+	mux.HandleFunc("/slow.js", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte(`window.slow = true;`))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var timings []ResourceTiming
+	if err := Run(ctx,
+		Navigate(s.URL),
+		ResourceTimings(&timings),
+	); err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[string]string)
+	for _, timing := range timings {
+		types[timing.Name] = timing.InitiatorType
+		if timing.Name == s.URL+"/slow.js" && (timing.Duration < 200 || timing.TransferSize <= 0) {
+			t.Errorf("expected /slow.js to take at least 200ms, got: %+v", timing)
+		}
+	}
+	if want := map[string]string{s.URL + "/slow.js": "script", s.URL + "/image.png": "img"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("expected resources %v, got: %v", want, types)
+	}
+}
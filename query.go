@@ -2,19 +2,26 @@ package chromedp
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/chromedp/cdproto/accessibility"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/css"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
 )
 
 // QueryAction are element query actions that select node elements from the
@@ -34,6 +41,17 @@ type Selector struct {
 	wait  func(context.Context, *cdp.Frame, ...cdp.NodeID) ([]*cdp.Node, error)
 	after func(context.Context, ...*cdp.Node) error
 	raw   bool
+
+	// padding is the margin added around the element by Screenshot.
+	padding float64
+
+	// dismissed is set by ToastDismissed, for WaitToast to also wait until
+	// the toast is dismissed.
+	dismissed bool
+
+	// err is an error found by an option, such as an invalid argument,
+	// returned by Do before querying anything.
+	err error
 }
 
 // Query is a query action that queries the browser for specific element
@@ -153,6 +171,9 @@ func Query(sel interface{}, opts ...QueryOption) QueryAction {
 // Do executes the selector, only finishing if the selector's by, wait, and
 // after funcs succeed, or if the context is cancelled.
 func (s *Selector) Do(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
 	t := cdp.ExecutorFromContext(ctx).(*Target)
 	if t == nil {
 		return ErrInvalidTarget
@@ -473,6 +494,24 @@ func NodeEnabled(s *Selector) {
 	}))(s)
 }
 
+// NodeDisabled is an element query option to wait until all queried element
+// nodes have been sent by the browser and are disabled (ie, have a 'disabled'
+// attribute).
+func NodeDisabled(s *Selector) {
+	WaitFunc(s.waitReady(func(ctx context.Context, n *cdp.Node) error {
+		n.RLock()
+		defer n.RUnlock()
+
+		for i := 0; i < len(n.Attributes); i += 2 {
+			if n.Attributes[i] == "disabled" {
+				return nil
+			}
+		}
+
+		return ErrEnabled
+	}))(s)
+}
+
 // NodeSelected is an element query option to wait until all queried element
 // nodes have been sent by the browser and are selected (ie, has 'selected'
 // attribute).
@@ -491,6 +530,26 @@ func NodeSelected(s *Selector) {
 	}))(s)
 }
 
+// NodeAttributePresent is an element query option to wait until all queried
+// element nodes have been sent by the browser and have the attribute, whatever
+// its value.
+func NodeAttributePresent(name string) QueryOption {
+	return func(s *Selector) {
+		WaitFunc(s.waitReady(func(ctx context.Context, n *cdp.Node) error {
+			n.RLock()
+			defer n.RUnlock()
+
+			for i := 0; i < len(n.Attributes); i += 2 {
+				if n.Attributes[i] == name {
+					return nil
+				}
+			}
+
+			return ErrAttributeNotPresent
+		}))(s)
+	}
+}
+
 // NodeNotPresent is an element query option to wait until no elements are
 // present that match the query.
 //
@@ -548,12 +607,26 @@ func WaitEnabled(sel interface{}, opts ...QueryOption) QueryAction {
 	return Query(sel, append(opts, NodeEnabled)...)
 }
 
+// WaitDisabled is an element query action that waits until the element
+// matching the selector is disabled (ie, has attribute 'disabled').
+func WaitDisabled(sel interface{}, opts ...QueryOption) QueryAction {
+	return Query(sel, append(opts, NodeDisabled)...)
+}
+
 // WaitSelected is an element query action that waits until the element
 // matching the selector is selected (ie, has attribute 'selected').
 func WaitSelected(sel interface{}, opts ...QueryOption) QueryAction {
 	return Query(sel, append(opts, NodeSelected)...)
 }
 
+// WaitAttributePresent is an element query action that waits until the element
+// matching the selector has the attribute, whatever its value. Useful for pages
+// which flag elements once they are ready, such as with a data-loaded
+// attribute.
+func WaitAttributePresent(sel interface{}, name string, opts ...QueryOption) QueryAction {
+	return Query(sel, append(opts, NodeAttributePresent(name))...)
+}
+
 // WaitNotPresent is an element query action that waits until no elements are
 // present matching the selector.
 func WaitNotPresent(sel interface{}, opts ...QueryOption) QueryAction {
@@ -626,6 +699,23 @@ func Blur(sel interface{}, opts ...QueryOption) QueryAction {
 	}, opts...)
 }
 
+// SelectText is an element query action that selects all the text of the first
+// element node matching the selector, as if the user selected it, such as to
+// test copying the selected text. Text fields are focused, and their value is
+// selected within them.
+//
+// The selection is made via the Selection API, so the selectionchange event is
+// fired by the browser as usual.
+func SelectText(sel interface{}, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return EvaluateAsDevTools(snippet(selectTextJS, cashX(true), sel, nodes[0]), &[]byte{}).Do(ctx)
+	}, opts...)
+}
+
 // Dimensions is an element query action that retrieves the box model dimensions for the
 // first element node matching the selector.
 func Dimensions(sel interface{}, model **dom.BoxModel, opts ...QueryOption) QueryAction {
@@ -642,6 +732,83 @@ func Dimensions(sel interface{}, model **dom.BoxModel, opts ...QueryOption) Quer
 	}, opts...)
 }
 
+// ResizeElement is an element query action that resizes the first element
+// node matching the selector to width and height CSS pixels, via its inline
+// style, and then waits until the ResizeObserver callbacks of the page have
+// been notified of the new size.
+//
+// Useful to test components reacting to the size of their container, such as
+// the ones reflowing their content via a ResizeObserver, without resizing the
+// viewport.
+func ResizeElement(sel interface{}, width, height int, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var res bool
+		return EvaluateAsDevTools(snippet(resizeElementJS, cashX(true), sel, nodes[0], width, height), &res, evalAwaitPromise).Do(ctx)
+	}, opts...)
+}
+
+// ContentQuads is an element query action that retrieves the quads describing
+// the content of the first element node matching the selector, in viewport
+// coordinates. Inline elements which wrap over several lines have one quad per
+// line, unlike their box model; see Dimensions.
+func ContentQuads(sel interface{}, quads *[]dom.Quad, opts ...QueryOption) QueryAction {
+	if quads == nil {
+		panic("quads cannot be nil")
+	}
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+		var err error
+		*quads, err = dom.GetContentQuads().WithNodeID(nodes[0].NodeID).Do(ctx)
+		return err
+	}, opts...)
+}
+
+// CanvasData is an element query action that retrieves the content of the
+// first canvas element node matching the selector, encoded as an image of the
+// specified MIME type (eg, "image/png" or "image/jpeg"). An empty or
+// unsupported format results in a PNG image.
+//
+// An error is returned when the canvas can't be exported, such as when it is
+// tainted by cross-origin images.
+func CanvasData(sel interface{}, res *[]byte, format string, opts ...QueryOption) QueryAction {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var data struct {
+			URL   string `json:"url"`
+			Error string `json:"error"`
+		}
+		if err := EvaluateAsDevTools(snippet(canvasDataJS, cashX(true), sel, nodes[0], format), &data).Do(ctx); err != nil {
+			return err
+		}
+		if data.Error != "" {
+			return fmt.Errorf("could not export canvas %q: %s", sel, data.Error)
+		}
+		i := strings.Index(data.URL, ";base64,")
+		if i < 0 {
+			return fmt.Errorf("could not export canvas %q: invalid data URL", sel)
+		}
+		buf, err := base64.StdEncoding.DecodeString(data.URL[i+len(";base64,"):])
+		if err != nil {
+			return err
+		}
+		*res = buf
+		return nil
+	}, opts...)
+}
+
 // Text is an element query action that retrieves the visible text of the first element
 // node matching the selector.
 func Text(sel interface{}, text *string, opts ...QueryOption) QueryAction {
@@ -754,6 +921,45 @@ func SetValue(sel interface{}, value string, opts ...QueryOption) QueryAction {
 	return SetJavascriptAttribute(sel, "value", value, opts...)
 }
 
+// FillForm is an element query action that sets the values of the fields of the
+// first form element node matching the selector.
+//
+// Each key in values is matched against the form's fields by name, then by id,
+// and then by the text of an associated label element. Text fields, textareas
+// and selects have their value set, radio buttons with a matching value are
+// checked, and checkboxes are checked unless the value is one of "", "0",
+// "false", or "off". The input and change events are dispatched on each field
+// that is set.
+//
+// If any key doesn't match a field, or a value doesn't match any of a field's
+// options, an error describing all such keys is returned and no field is
+// modified.
+func FillForm(sel interface{}, values map[string]string, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		if nodes[0].NodeName != "FORM" {
+			return fmt.Errorf("selector %q matched node %d with name %s", sel, nodes[0].NodeID, strings.ToLower(nodes[0].NodeName))
+		}
+
+		buf, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+
+		var problems []string
+		if err := EvaluateAsDevTools(snippet(fillFormJS, cashX(true), sel, nodes[0], string(buf)), &problems).Do(ctx); err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("could not fill form %q: %s", sel, strings.Join(problems, ", "))
+		}
+		return nil
+	}, opts...)
+}
+
 // Attributes is an element query action that retrieves the element attributes for the
 // first element node matching the selector.
 func Attributes(sel interface{}, attributes *map[string]string, opts ...QueryOption) QueryAction {
@@ -935,6 +1141,42 @@ func OuterHTML(sel interface{}, html *string, opts ...QueryOption) QueryAction {
 	return JavascriptAttribute(sel, "outerHTML", html, opts...)
 }
 
+// OuterHTMLAll is an element query action that retrieves the outer html of all
+// element nodes matching the selector, in the order they were matched.
+//
+// Note: this should be used with the ByQueryAll query option.
+func OuterHTMLAll(sel interface{}, html *[]string, opts ...QueryOption) QueryAction {
+	if html == nil {
+		panic("html cannot be nil")
+	}
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		res := make([]string, len(nodes))
+		errs := make([]error, len(nodes))
+		var wg sync.WaitGroup
+		for i, n := range nodes {
+			wg.Add(1)
+			go func(i int, n *cdp.Node) {
+				defer wg.Done()
+				res[i], errs[i] = dom.GetOuterHTML().WithNodeID(n.NodeID).Do(ctx)
+			}(i, n)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+
+		*html = res
+		return nil
+	}, opts...)
+}
+
 // InnerHTML is an element query action that retrieves the inner html of the first
 // element node matching the selector.
 func InnerHTML(sel interface{}, html *string, opts ...QueryOption) QueryAction {
@@ -956,6 +1198,118 @@ func Click(sel interface{}, opts ...QueryOption) QueryAction {
 	}, append(opts, NodeVisible)...)
 }
 
+// ClickOutcome is the outcome of a ClickAndWait action.
+type ClickOutcome string
+
+// ClickOutcome values.
+const (
+	// ClickNavigated is the outcome of a click that navigated the current
+	// frame, once the new page has loaded.
+	ClickNavigated ClickOutcome = "navigated"
+
+	// ClickDownloaded is the outcome of a click that started a download.
+	ClickDownloaded ClickOutcome = "downloaded"
+)
+
+// ClickAndWait is an element query action that sends a mouse click event to the
+// first element node matching the selector, and then waits until either the
+// current frame has navigated to and loaded a new page, or a download has
+// begun. The outcome is stored in res.
+//
+// Note: headless browsers deny downloads by default, in which case
+// page.SetDownloadBehavior should be used before clicking.
+func ClickAndWait(sel interface{}, res *ClickOutcome, opts ...QueryOption) QueryAction {
+	if res == nil {
+		panic("res cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var outcome ClickOutcome
+		expect, release := expectEvent(ctx, func(ev interface{}) bool {
+			switch e := ev.(type) {
+			case *page.EventLifecycleEvent:
+				if e.Name == "load" && e.FrameID == navigatedFrameID(ctx) {
+					outcome = ClickNavigated
+					return true
+				}
+			case *page.EventDownloadWillBegin:
+				outcome = ClickDownloaded
+				return true
+			}
+			return false
+		})
+		defer release()
+
+		if err := MouseClickNode(nodes[0]).Do(ctx); err != nil {
+			return err
+		}
+		if err := expect(); err != nil {
+			return err
+		}
+		*res = outcome
+		return nil
+	}, append(opts, NodeVisible)...)
+}
+
+// ClickOpensTab is an element query action that sends a mouse click event to
+// the first element node matching the selector, and then waits until the click
+// opens a new tab with a URL matching urlPattern. The new tab is attached to,
+// and its context and the func cancelling it are stored in newCtx and cancel.
+//
+// Since the tab is waited for before clicking, no new tab is missed, unlike
+// running WaitNewTarget after the click. The tab is closed when cancel is
+// called, as with the cancel func returned by NewContext.
+func ClickOpensTab(sel interface{}, urlPattern *regexp.Regexp, newCtx *context.Context, cancel *context.CancelFunc, opts ...QueryOption) QueryAction {
+	if urlPattern == nil {
+		panic("urlPattern cannot be nil")
+	}
+	if newCtx == nil {
+		panic("newCtx cannot be nil")
+	}
+	if cancel == nil {
+		panic("cancel cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+
+		wctx, wcancel := context.WithCancel(ctx)
+		defer wcancel()
+		ch := WaitNewTarget(wctx, func(info *target.Info) bool {
+			return info.OpenerID == t.TargetID && info.Type == "page" && urlPattern.MatchString(info.URL)
+		})
+
+		if err := MouseClickNode(nodes[0]).Do(ctx); err != nil {
+			return err
+		}
+
+		var id target.ID
+		select {
+		case id = <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		tctx, tcancel := NewContext(ctx, WithTargetID(id))
+		if err := Run(tctx); err != nil {
+			tcancel()
+			return err
+		}
+		*newCtx, *cancel = tctx, tcancel
+		return nil
+	}, append(opts, NodeVisible)...)
+}
+
 // DoubleClick is an element query action that sends a mouse double click event to the
 // first element node matching the selector.
 func DoubleClick(sel interface{}, opts ...QueryOption) QueryAction {
@@ -968,6 +1322,20 @@ func DoubleClick(sel interface{}, opts ...QueryOption) QueryAction {
 	}, append(opts, NodeVisible)...)
 }
 
+// MiddleClick is an element query action that sends a mouse middle button
+// click event to the first element node matching the selector. The page
+// receives an auxclick event, and links are opened in a new background tab;
+// use WaitNewTarget beforehand to wait for it.
+func MiddleClick(sel interface{}, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return MouseClickNode(nodes[0], ButtonMiddle).Do(ctx)
+	}, append(opts, NodeVisible)...)
+}
+
 // SendKeys is an element query action that synthesizes the key up, char, and down
 // events as needed for the runes in v, sending them to the first element node
 // matching the selector.
@@ -1004,6 +1372,54 @@ func SendKeys(sel interface{}, v string, opts ...QueryOption) QueryAction {
 	}, append(opts, NodeVisible)...)
 }
 
+// CompositionInput is an element query action that simulates IME (input method
+// editor) composition of text on the first element node matching the selector.
+//
+// The node is focused, and the compositionstart event is dispatched, followed
+// by a compositionupdate event for each rune of text, with the text composed so
+// far. Then, the text is committed via input.InsertText, and the compositionend
+// event is dispatched.
+//
+// Useful for testing input handling for languages such as Chinese, Japanese or
+// Korean, which SendKeys bypasses.
+func CompositionInput(sel interface{}, text string, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		n := nodes[0]
+		if err := dom.Focus().WithNodeID(n.NodeID).Do(ctx); err != nil {
+			return err
+		}
+
+		var updates []string
+		for i := range text {
+			if i > 0 {
+				updates = append(updates, text[:i])
+			}
+		}
+		updates = append(updates, text)
+		updatesJSON, err := json.Marshal(updates)
+		if err != nil {
+			return err
+		}
+		textJSON, err := json.Marshal(text)
+		if err != nil {
+			return err
+		}
+
+		var res bool
+		if err := EvaluateAsDevTools(snippet(compositionStartJS, cashX(true), sel, n, string(updatesJSON)), &res).Do(ctx); err != nil {
+			return err
+		}
+		if err := input.InsertText(text).Do(ctx); err != nil {
+			return err
+		}
+		return EvaluateAsDevTools(snippet(compositionEndJS, cashX(true), sel, n, string(textJSON)), &res).Do(ctx)
+	}, append(opts, NodeVisible)...)
+}
+
 // SetUploadFiles is an element query action that sets the files to upload (ie, for a
 // input[type="file"] node) for the first element node matching the selector.
 func SetUploadFiles(sel interface{}, files []string, opts ...QueryOption) QueryAction {
@@ -1017,7 +1433,8 @@ func SetUploadFiles(sel interface{}, files []string, opts ...QueryOption) QueryA
 }
 
 // Screenshot is an element query action that takes a screenshot of the first element
-// node matching the selector.
+// node matching the selector. The Padding option expands the captured area
+// around the element.
 //
 // See CaptureScreenshot for capturing a screenshot of the browser viewport.
 //
@@ -1028,7 +1445,8 @@ func Screenshot(sel interface{}, picbuf *[]byte, opts ...QueryOption) QueryActio
 		panic("picbuf cannot be nil")
 	}
 
-	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+	var s *Selector
+	s = QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
 		if len(nodes) < 1 {
 			return fmt.Errorf("selector %q did not return any nodes", sel)
 		}
@@ -1042,16 +1460,29 @@ func Screenshot(sel interface{}, picbuf *[]byte, opts ...QueryOption) QueryActio
 			return ErrInvalidBoxModel
 		}
 
+		x0, y0, x1, y1 := box.Margin[0], box.Margin[1], box.Margin[4], box.Margin[5]
+		if s.padding > 0 {
+			// expand the box, without going past the page's bounds
+			_, _, content, err := page.GetLayoutMetrics().Do(ctx)
+			if err != nil {
+				return err
+			}
+			x0 = math.Max(x0-s.padding, 0)
+			y0 = math.Max(y0-s.padding, 0)
+			x1 = math.Min(x1+s.padding, content.Width)
+			y1 = math.Min(y1+s.padding, content.Height)
+		}
+
 		// take screenshot of the box
 		buf, err := page.CaptureScreenshot().
 			WithFormat(page.CaptureScreenshotFormatPng).
 			WithClip(&page.Viewport{
 				// Round the dimensions, as otherwise we might
 				// lose one pixel in either dimension.
-				X:      math.Round(box.Margin[0]),
-				Y:      math.Round(box.Margin[1]),
-				Width:  math.Round(box.Margin[4] - box.Margin[0]),
-				Height: math.Round(box.Margin[5] - box.Margin[1]),
+				X:      math.Round(x0),
+				Y:      math.Round(y0),
+				Width:  math.Round(x1 - x0),
+				Height: math.Round(y1 - y0),
 				// This seems to be necessary? Seems to do the
 				// right thing regardless of DPI.
 				Scale: 1.0,
@@ -1062,7 +1493,21 @@ func Screenshot(sel interface{}, picbuf *[]byte, opts ...QueryOption) QueryActio
 
 		*picbuf = buf
 		return nil
-	}, append(opts, NodeVisible)...)
+	}, append(opts, NodeVisible)...).(*Selector)
+	return s
+}
+
+// Padding is an element query option for the Screenshot action to expand the
+// captured area by px CSS pixels on every side of the element, so that its
+// box shadow and the decorations overflowing it aren't clipped. The captured
+// area is clamped to the page's bounds.
+func Padding(px float64) QueryOption {
+	if px < 0 {
+		panic("px cannot be negative")
+	}
+	return func(s *Selector) {
+		s.padding = px
+	}
 }
 
 // Submit is an element query action that submits the parent form of the first element
@@ -1109,11 +1554,17 @@ func Reset(sel interface{}, opts ...QueryOption) QueryAction {
 	}, opts...)
 }
 
-// ComputedStyle is an element query action that retrieves the computed style of the
-// first element node matching the selector.
-func ComputedStyle(sel interface{}, style *[]*css.ComputedStyleProperty, opts ...QueryOption) QueryAction {
-	if style == nil {
-		panic("style cannot be nil")
+// ReportValidity is an element query action that checks the validity of the
+// parent form of the first element node matching the selector, as with
+// form.reportValidity, without submitting it. Whether the form is valid is
+// stored in valid, and the validation messages of its invalid fields, keyed by
+// their name, or id when unnamed, are stored in messages.
+func ReportValidity(sel interface{}, valid *bool, messages *map[string]string, opts ...QueryOption) QueryAction {
+	if valid == nil {
+		panic("valid cannot be nil")
+	}
+	if messages == nil {
+		panic("messages cannot be nil")
 	}
 
 	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
@@ -1121,20 +1572,27 @@ func ComputedStyle(sel interface{}, style *[]*css.ComputedStyleProperty, opts ..
 			return fmt.Errorf("selector %q did not return any nodes", sel)
 		}
 
-		computed, err := css.GetComputedStyleForNode(nodes[0].NodeID).Do(ctx)
+		var res *struct {
+			Valid    bool              `json:"valid"`
+			Messages map[string]string `json:"messages"`
+		}
+		err := EvaluateAsDevTools(snippet(reportValidityJS, cashX(true), sel, nodes[0]), &res).Do(ctx)
 		if err != nil {
 			return err
 		}
 
-		*style = computed
+		if res == nil {
+			return fmt.Errorf("could not call reportValidity on node %d", nodes[0].NodeID)
+		}
 
+		*valid, *messages = res.Valid, res.Messages
 		return nil
 	}, opts...)
 }
 
-// MatchedStyle is an element query action that retrieves the matched style information
-// for the first element node matching the selector.
-func MatchedStyle(sel interface{}, style **css.GetMatchedStylesForNodeReturns, opts ...QueryOption) QueryAction {
+// ComputedStyle is an element query action that retrieves the computed style of the
+// first element node matching the selector.
+func ComputedStyle(sel interface{}, style *[]*css.ComputedStyleProperty, opts ...QueryOption) QueryAction {
 	if style == nil {
 		panic("style cannot be nil")
 	}
@@ -1144,21 +1602,254 @@ func MatchedStyle(sel interface{}, style **css.GetMatchedStylesForNodeReturns, o
 			return fmt.Errorf("selector %q did not return any nodes", sel)
 		}
 
-		var err error
-		ret := &css.GetMatchedStylesForNodeReturns{}
-		ret.InlineStyle, ret.AttributesStyle, ret.MatchedCSSRules,
-			ret.PseudoElements, ret.Inherited, ret.CSSKeyframesRules,
-			err = css.GetMatchedStylesForNode(nodes[0].NodeID).Do(ctx)
+		computed, err := css.GetComputedStyleForNode(nodes[0].NodeID).Do(ctx)
 		if err != nil {
 			return err
 		}
 
-		*style = ret
+		*style = computed
 
 		return nil
 	}, opts...)
 }
 
+// PseudoStyle is an element query action that retrieves the computed style of
+// the pseudo element of type pseudo, such as "before" or "::after", of the
+// first element node matching the selector.
+//
+// Useful to check the content of pseudo elements, such as the icons rendered
+// via the content property and an icon font. The pseudo element is resolved via
+// the pseudo elements reported by dom.DescribeNode.
+func PseudoStyle(sel interface{}, pseudo string, style *[]*css.ComputedStyleProperty, opts ...QueryOption) QueryAction {
+	if style == nil {
+		panic("style cannot be nil")
+	}
+	typ := cdp.PseudoType(strings.TrimPrefix(pseudo, "::"))
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		node, err := dom.DescribeNode().WithNodeID(nodes[0].NodeID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		var backendNodeID cdp.BackendNodeID
+		for _, n := range node.PseudoElements {
+			if n.PseudoType == typ {
+				backendNodeID = n.BackendNodeID
+			}
+		}
+		if backendNodeID == 0 {
+			return fmt.Errorf("node %d has no %s pseudo element", nodes[0].NodeID, typ)
+		}
+
+		ids, err := dom.PushNodesByBackendIdsToFrontend([]cdp.BackendNodeID{backendNodeID}).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		computed, err := css.GetComputedStyleForNode(ids[0]).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		*style = computed
+
+		return nil
+	}, opts...)
+}
+
+// MatchedStyle is an element query action that retrieves the matched style information
+// for the first element node matching the selector.
+func MatchedStyle(sel interface{}, style **css.GetMatchedStylesForNodeReturns, opts ...QueryOption) QueryAction {
+	if style == nil {
+		panic("style cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var err error
+		ret := &css.GetMatchedStylesForNodeReturns{}
+		ret.InlineStyle, ret.AttributesStyle, ret.MatchedCSSRules,
+			ret.PseudoElements, ret.Inherited, ret.CSSKeyframesRules,
+			err = css.GetMatchedStylesForNode(nodes[0].NodeID).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		*style = ret
+
+		return nil
+	}, opts...)
+}
+
+// CSSVariables is an element query action that retrieves the resolved values
+// of the CSS custom properties (ie, variables such as "--primary-color") in
+// scope for the first element node matching the selector, keyed by their name.
+// Properties which resolve to an empty value are omitted.
+//
+// Useful to check the effect of theme switching on pages that toggle CSS
+// variables. The names of the properties are collected from the stylesheets of
+// the document and the inline styles, so the variables declared in
+// cross-origin stylesheets may be missing.
+func CSSVariables(sel interface{}, vars *map[string]string, opts ...QueryOption) QueryAction {
+	if vars == nil {
+		panic("vars cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return EvaluateAsDevTools(snippet(cssVariablesJS, cashX(true), sel, nodes[0]), vars).Do(ctx)
+	}, opts...)
+}
+
+// UsedFonts is an element query action that retrieves the platform fonts used
+// to render the text of the first element node matching the selector, along
+// with the number of glyphs rendered with each of them.
+//
+// Useful to check that a web font was actually used, as opposed to one of the
+// fallback fonts of the font-family property.
+func UsedFonts(sel interface{}, fonts *[]*css.PlatformFontUsage, opts ...QueryOption) QueryAction {
+	if fonts == nil {
+		panic("fonts cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var err error
+		*fonts, err = css.GetPlatformFontsForNode(nodes[0].NodeID).Do(ctx)
+		return err
+	}, opts...)
+}
+
+// StackingOrder is an action that computes the order in which the first
+// element nodes matching each of the selectors are painted, storing the
+// indexes of the selectors in order, from the element painted on top of the
+// others to the one painted at the bottom.
+//
+// The order is computed from the stacking contexts the elements belong to, like
+// the browser does, rather than from their raw z-index; as such, it's useful to
+// debug why an element with a higher z-index is painted below another one. The
+// elements don't need to overlap. Elements which aren't positioned, and don't
+// create a stacking context, are ordered in tree order, regardless of whether
+// they're floats or inline elements.
+func StackingOrder(sels []interface{}, order *[]int, opts ...QueryOption) Action {
+	if order == nil {
+		panic("order cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		exprs := make([]string, len(sels))
+		for i, sel := range sels {
+			i, sel := i, sel
+			if err := QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+				if len(nodes) < 1 {
+					return fmt.Errorf("selector %q did not return any nodes", sel)
+				}
+				exprs[i] = snippet("%s", cashX(true), sel, nodes[0])
+				return nil
+			}, opts...).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return EvaluateAsDevTools(fmt.Sprintf(stackingOrderJS, "["+strings.Join(exprs, ", ")+"]"), order).Do(ctx)
+	})
+}
+
+// UniqueSelector is an action that computes the shortest CSS selector which
+// only matches the element node, storing it in out.
+//
+// The selector is a path of child combinators, starting from the closest
+// ancestor with a unique ID, or from the root, which is only as long as needed
+// to be unique; siblings with the same tag name are distinguished with
+// :nth-child. For elements in a shadow tree, the selector is relative to the
+// shadow root. Useful to generate stable selectors for elements found while
+// exploring a page, such as to write tests.
+func UniqueSelector(node *cdp.Node, out *string) Action {
+	if node == nil {
+		panic("node cannot be nil")
+	}
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return EvaluateAsDevTools(fmt.Sprintf(uniqueSelectorJS, cashX(true)(node)), out)
+}
+
+// NodeByRole is an action that waits until the accessibility tree of the
+// current page has a node with the ARIA role, such as "button" or "heading",
+// and the accessible name, storing the first such node's backing element node
+// in node. An empty name matches any name.
+//
+// Querying by role and name, as users perceive the page, is usually more
+// robust than querying by CSS selectors, which depend on the page's markup.
+// Nodes ignored for accessibility, such as hidden ones, never match.
+//
+// Note: the Accessibility domain is enabled.
+func NodeByRole(role, name string, node **cdp.Node) Action {
+	if node == nil {
+		panic("node cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := accessibility.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		var backendNodeID cdp.BackendNodeID
+		if err := waitFor(ctx, 100*time.Millisecond, func(ctx context.Context) (bool, error) {
+			nodes, err := accessibility.GetFullAXTree().Do(ctx)
+			if err != nil {
+				return false, err
+			}
+			for _, n := range nodes {
+				if n.Ignored || n.BackendDOMNodeID == 0 {
+					continue
+				}
+				if axValueString(n.Role) == role && (name == "" || axValueString(n.Name) == name) {
+					backendNodeID = n.BackendDOMNodeID
+					return true, nil
+				}
+			}
+			return false, nil
+		}); err != nil {
+			return err
+		}
+
+		ids, err := dom.PushNodesByBackendIdsToFrontend([]cdp.BackendNodeID{backendNodeID}).Do(ctx)
+		if err != nil {
+			return err
+		}
+		var nodes []*cdp.Node
+		if err := Nodes(ids, &nodes, ByNodeID).Do(ctx); err != nil {
+			return err
+		}
+		*node = nodes[0]
+		return nil
+	})
+}
+
+// axValueString returns the string value of the accessibility value, or an
+// empty string when it's not one.
+func axValueString(v *accessibility.Value) string {
+	var s string
+	if v != nil {
+		json.Unmarshal(v.Value, &s)
+	}
+	return s
+}
+
 // ScrollIntoView is an element query action that scrolls the window to the
 // first element node matching the selector.
 func ScrollIntoView(sel interface{}, opts ...QueryOption) QueryAction {
@@ -1180,3 +1871,411 @@ func ScrollIntoView(sel interface{}, opts ...QueryOption) QueryAction {
 		return nil
 	}, opts...)
 }
+
+// PinIntoView is an element query action that scrolls the window so that the
+// first element node matching the selector is at the top of the viewport, and
+// then keeps the window at that scroll position, scrolling it back whenever
+// it's scrolled by the user or the page, until UnpinView is run.
+//
+// Useful for capturing several screenshots of the same element during an
+// interaction, as any scroll drift would make them misaligned. Scrollable
+// elements within the page can still be scrolled.
+func PinIntoView(sel interface{}, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var pos []float64
+		if err := EvaluateAsDevTools(snippet(pinIntoViewJS, cashX(true), sel, nodes[0]), &pos).Do(ctx); err != nil {
+			return err
+		}
+
+		if pos == nil {
+			return fmt.Errorf("could not pin node %d into view", nodes[0].NodeID)
+		}
+
+		return nil
+	}, opts...)
+}
+
+// UnpinView is an action that releases the scroll position of the window
+// pinned by PinIntoView. It does nothing if no position is pinned.
+func UnpinView() Action {
+	return EvaluateAsDevTools(unpinViewJS, &[]byte{})
+}
+
+// InViewport is an element query action that retrieves whether the first
+// element node matching the selector is fully within the layout viewport.
+//
+// See InViewportRatio to allow elements to be partially visible.
+func InViewport(sel interface{}, inside *bool, opts ...QueryOption) QueryAction {
+	return InViewportRatio(sel, 1, inside, opts...)
+}
+
+// InViewportRatio is an element query action that retrieves whether at least
+// the ratio (between 0 and 1) of the area of the first element node matching
+// the selector is within the layout viewport.
+func InViewportRatio(sel interface{}, ratio float64, inside *bool, opts ...QueryOption) QueryAction {
+	if inside == nil {
+		panic("inside cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return EvaluateAsDevTools(snippet(viewportRatioJS, cashX(true), sel, nodes[0], ratio), inside).Do(ctx)
+	}, opts...)
+}
+
+// VisibilityRatio is an element query action that retrieves the ratio (between
+// 0 and 1) of the area of the first element node matching the selector which
+// is visible within the viewport, as computed by an IntersectionObserver.
+//
+// Unlike InViewportRatio, the parts of the element clipped by its ancestors,
+// such as by a scroll container, don't count as visible.
+func VisibilityRatio(sel interface{}, ratio *float64, opts ...QueryOption) QueryAction {
+	if ratio == nil {
+		panic("ratio cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return EvaluateAsDevTools(snippet(visibilityRatioJS, cashX(true), sel, nodes[0]), ratio, evalAwaitPromise).Do(ctx)
+	}, opts...)
+}
+
+// WaitAnimationsFinished is an element query action that waits until the
+// animations of the first element node matching the selector and of its
+// descendants have finished, as reported by the finished promises of
+// element.getAnimations, including CSS animations and transitions, and the
+// ones started via the Web Animations API.
+//
+// Useful to capture a component only once its entrance animation has
+// completed. Infinite animations are ignored, while paused animations are waited
+// for until they are resumed, or until ctx is done.
+func WaitAnimationsFinished(sel interface{}, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		var res bool
+		return EvaluateAsDevTools(snippet(animationsFinishedJS, cashX(true), sel, nodes[0]), &res, evalAwaitPromise).Do(ctx)
+	}, opts...)
+}
+
+// WaitComputedStyle is an element query action that waits until the property
+// of the computed style of the first element node matching the selector is
+// equal to value, such as the opacity reaching "1" at the end of a fade-in.
+//
+// Note: the computed style is polled, so values only reached in the middle of
+// a transition may be missed.
+func WaitComputedStyle(sel interface{}, property, value string, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			var current string
+			if err := EvaluateAsDevTools(snippet(computedStylePropertyJS, cashX(true), sel, nodes[0], property), &current).Do(ctx); err != nil {
+				return false, err
+			}
+			return current == value, nil
+		})
+	}, opts...)
+}
+
+// NodeCount is an action that retrieves the number of element nodes in the
+// current document.
+//
+// Useful for guarding against regressions in the size of the DOM, which
+// affects the memory usage and rendering performance of a page.
+func NodeCount(count *int) Action {
+	if count == nil {
+		panic("count cannot be nil")
+	}
+	return EvaluateAsDevTools(nodeCountJS, count)
+}
+
+// SubtreeNodeCount is an element query action that retrieves the number of
+// element nodes in the subtree rooted at the first element node matching the
+// selector, including the node itself.
+func SubtreeNodeCount(sel interface{}, count *int, opts ...QueryOption) QueryAction {
+	if count == nil {
+		panic("count cannot be nil")
+	}
+
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return EvaluateAsDevTools(snippet(subtreeNodeCountJS, cashX(true), sel, nodes[0]), count).Do(ctx)
+	}, opts...)
+}
+
+// WaitToast is an element query action that waits until the first element
+// node matching the selector (ie, a toast) is visible, and captures its text
+// right away, before it is dismissed. Use the ToastDismissed option to also
+// wait until the toast is removed or not visible anymore.
+func WaitToast(sel interface{}, text *string, opts ...QueryOption) QueryAction {
+	if text == nil {
+		panic("text cannot be nil")
+	}
+
+	var s *Selector
+	s = QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+
+		return EvaluateAsDevTools(snippet(toastJS, cashX(true), s, nodes[0], s.dismissed), text, evalAwaitPromise).Do(ctx)
+	}, append(opts, NodeVisible)...).(*Selector)
+	return s
+}
+
+// ToastDismissed is an element query option for the WaitToast action to also
+// wait until the toast is removed or not visible anymore.
+func ToastDismissed(s *Selector) {
+	s.dismissed = true
+}
+
+// mutationsBinding is the name of the binding used by WaitMutations to report
+// back from the page.
+const mutationsBinding = "chromedpMutations"
+
+// mutationsToken is used to tell concurrent WaitMutations actions apart.
+var mutationsToken int64
+
+// WaitMutations is an action that waits until the subtree of the first element
+// node matching the selector undergoes the specified number of DOM mutations,
+// counted as MutationObserver records: added or removed children, modified
+// attributes, and modified text.
+//
+// Only the mutations made after the element is selected are counted.
+func WaitMutations(sel interface{}, count int, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+		if count < 1 {
+			return fmt.Errorf("invalid mutation count %d", count)
+		}
+		token := strconv.FormatInt(atomic.AddInt64(&mutationsToken, 1), 10)
+
+		done := make(chan struct{})
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*runtime.EventBindingCalled)
+			if !ok || e.Name != mutationsBinding || e.Payload != token {
+				return
+			}
+			close(done)
+			cancel()
+		})
+
+		if err := runtime.AddBinding(mutationsBinding).Do(ctx); err != nil {
+			return err
+		}
+		var res bool
+		if err := EvaluateAsDevTools(snippet(mutationsJS, cashX(true), sel, nodes[0], mutationsBinding, token, count), &res).Do(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, opts...)
+}
+
+// transitionBinding is the name of the binding used by WaitTransitionStart to
+// report back from the page.
+const transitionBinding = "chromedpTransitionStart"
+
+// transitionToken is used to tell concurrent WaitTransitionStart actions
+// apart.
+var transitionToken int64
+
+// WaitTransitionStart is an action that waits until a CSS transition of the
+// property, such as "opacity" or "background-color", starts on the first
+// element node matching the selector, as reported by the transitionstart event.
+// An empty property matches the transitions of any property. Transitions which
+// are already running when the element is selected match as well.
+//
+// Useful to check that an interaction actually starts a transition; combine it
+// with a context timeout to fail when it never does. Note that the properties
+// of transitions are reported as longhand properties, so shorthands such as
+// "border" never match.
+func WaitTransitionStart(sel interface{}, property string, opts ...QueryOption) QueryAction {
+	return QueryAfter(sel, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", sel)
+		}
+		token := strconv.FormatInt(atomic.AddInt64(&transitionToken, 1), 10)
+
+		done := make(chan struct{})
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ListenTarget(lctx, func(ev interface{}) {
+			e, ok := ev.(*runtime.EventBindingCalled)
+			if !ok || e.Name != transitionBinding || e.Payload != token {
+				return
+			}
+			close(done)
+			cancel()
+		})
+
+		if err := runtime.AddBinding(transitionBinding).Do(ctx); err != nil {
+			return err
+		}
+		var res bool
+		if err := EvaluateAsDevTools(snippet(transitionStartJS, cashX(true), sel, nodes[0], transitionBinding, token, property), &res).Do(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, opts...)
+}
+
+// WaitVisibleAnyFrame is an action that waits until an element matching the CSS
+// selector is visible in any frame of the current page, be it the top level
+// frame or any of its (nested) iframes.
+//
+// Useful when it isn't known which frame the element will be rendered in.
+// Each frame is searched via a dedicated isolated world, so the page's scripts
+// cannot interfere with the search. Note that out-of-process iframes, which
+// belong to separate targets, are not searched.
+func WaitVisibleAnyFrame(sel string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		selJSON, err := json.Marshal(sel)
+		if err != nil {
+			return err
+		}
+		expr := fmt.Sprintf(anyVisibleJS, selJSON)
+
+		worlds := make(map[cdp.FrameID]runtime.ExecutionContextID)
+		return waitFor(ctx, 10*time.Millisecond, func(ctx context.Context) (bool, error) {
+			tree, err := page.GetFrameTree().Do(ctx)
+			if err != nil {
+				return false, err
+			}
+			for _, f := range frameTreeFrames(tree) {
+				id, ok := worlds[f.ID]
+				if !ok {
+					if id, err = page.CreateIsolatedWorld(f.ID).Do(ctx); err != nil {
+						// The frame may have been detached.
+						continue
+					}
+					worlds[f.ID] = id
+				}
+				v, exp, err := runtime.Evaluate(expr).WithContextID(id).WithReturnByValue(true).Do(ctx)
+				if err != nil {
+					// The world is destroyed when its frame navigates.
+					delete(worlds, f.ID)
+					continue
+				}
+				if exp != nil {
+					return false, exp
+				}
+				var visible bool
+				if err := json.Unmarshal(v.Value, &visible); err == nil && visible {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+	})
+}
+
+// frameTreeFrames returns the frames of the tree, in depth-first order.
+func frameTreeFrames(tree *page.FrameTree) []*cdp.Frame {
+	frames := []*cdp.Frame{tree.Frame}
+	for _, child := range tree.ChildFrames {
+		frames = append(frames, frameTreeFrames(child)...)
+	}
+	return frames
+}
+
+// WaitVisibleInContainer is an action that scrolls the first element node
+// matching containerSel, rather than the window, until the first element node
+// matching sel is visible within it, such as to reveal an item of a
+// virtualized list. The query options apply to both selectors.
+//
+// The container is scrolled down until an element matching sel is rendered
+// within it, and then towards that element. An error is returned when the
+// container can't be scrolled any further and the element still isn't visible.
+func WaitVisibleInContainer(containerSel, sel interface{}, opts ...QueryOption) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		var container *cdp.Node
+		if err := QueryAfter(containerSel, func(ctx context.Context, nodes ...*cdp.Node) error {
+			if len(nodes) < 1 {
+				return fmt.Errorf("selector %q did not return any nodes", containerSel)
+			}
+			container = nodes[0]
+			return nil
+		}, opts...).Do(ctx); err != nil {
+			return err
+		}
+		containerExpr := snippet("%s", cashX(true), containerSel, container)
+
+		// the page may still be rendering items when the end is reached
+		const maxEnds = 3
+		var ends int
+		return waitFor(ctx, 50*time.Millisecond, func(ctx context.Context) (bool, error) {
+			var nodes []*cdp.Node
+			if err := Nodes(sel, &nodes, append(opts, AtLeast(0))...).Do(ctx); err != nil {
+				return false, err
+			}
+			target := "null"
+			if len(nodes) > 0 {
+				target = snippet("%s", cashX(true), sel, nodes[0])
+			}
+			var res string
+			if err := EvaluateAsDevTools(fmt.Sprintf(scrollContainerJS, containerExpr, target), &res).Do(ctx); err != nil {
+				return false, err
+			}
+			switch res {
+			case "visible":
+				return true, nil
+			case "end":
+				if ends++; ends >= maxEnds {
+					return false, fmt.Errorf("selector %q did not become visible in container %q", sel, containerSel)
+				}
+			default:
+				ends = 0
+			}
+			return false, nil
+		})
+	})
+}
+
+// WaitContentLoaded is an action that waits until no element node matches
+// placeholderSel, such as the skeleton of a component shown while it loads,
+// and the first element node matching contentSel is visible. The query options
+// apply to both selectors.
+//
+// Useful for pages rendering skeleton screens, as the actual content may be
+// added to the page before the placeholders are removed, or the other way
+// around.
+func WaitContentLoaded(placeholderSel, contentSel interface{}, opts ...QueryOption) Action {
+	return Tasks{
+		WaitNotPresent(placeholderSel, opts...),
+		WaitVisible(contentSel, opts...),
+	}
+}
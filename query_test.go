@@ -2,13 +2,16 @@ package chromedp
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image/png"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
@@ -17,6 +20,8 @@ import (
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/css"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp/kb"
 )
 
@@ -124,6 +129,31 @@ func TestWaitEnabled(t *testing.T) {
 	}
 }
 
+func TestWaitDisabled(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<button id="save" onclick="var b = this; setTimeout(function() { b.disabled = true; }, 100);">save</button>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var ok bool
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Click(`#save`, ByID),
+		WaitDisabled(`#save`, ByID),
+		Evaluate(`document.getElementById('save').disabled`, &ok),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected the button to be disabled")
+	}
+}
+
 func TestWaitSelected(t *testing.T) {
 	t.Parallel()
 
@@ -318,6 +348,51 @@ func TestFocusBlur(t *testing.T) {
 	}
 }
 
+func TestSelectText(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<p id="text">hello <b>world</b></p>
+<input id="input" value="some value">
+<script>
+	window.changes = 0;
+	document.addEventListener('selectionchange', function() { window.changes++; });
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var text string
+	var changes int
+	var field []interface{}
+	if err := Run(ctx,
+		Navigate(s.URL),
+		SelectText(`#text`, ByID),
+		Evaluate(`window.getSelection().toString()`, &text),
+		// selectionchange is fired asynchronously
+		Evaluate(`new Promise(function(resolve) {
+			setTimeout(function() { resolve(window.changes); }, 50);
+		})`, &changes, evalAwaitPromise),
+		SelectText(`#input`, ByID),
+		Evaluate(`(function(el) {
+			return [document.activeElement === el, el.selectionStart, el.selectionEnd];
+		})(document.getElementById('input'))`, &field),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello world" {
+		t.Errorf("expected the text to be selected, got: %q", text)
+	}
+	if changes < 1 {
+		t.Error("expected a selectionchange event")
+	}
+	if want := []interface{}{true, 0.0, 10.0}; !reflect.DeepEqual(field, want) {
+		t.Errorf("expected the value of the field to be selected, got: %v", field)
+	}
+}
+
 func TestDimensions(t *testing.T) {
 	t.Parallel()
 
@@ -501,6 +576,40 @@ func TestReset(t *testing.T) {
 	}
 }
 
+func TestReportValidity(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<form id="signup">
+	<input name="email" type="email" value="not an email">
+	<input id="age" type="number" min="18" value="12">
+	<input name="nickname" value="gopher" required>
+</form>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, after bool
+	var invalid, fixed map[string]string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		ReportValidity("#signup", &before, &invalid, ByQuery),
+		SetValue(`input[name="email"]`, "gopher@example.com", ByQuery),
+		SetValue("#age", "21", ByQuery),
+		ReportValidity(`input[name="nickname"]`, &after, &fixed, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if before || len(invalid) != 2 || invalid["email"] == "" || invalid["age"] == "" {
+		t.Errorf("expected the email and age fields to be invalid, got: %v %v", before, invalid)
+	}
+	if !after || len(fixed) != 0 {
+		t.Errorf("expected the form to be valid, got: %v %v", after, fixed)
+	}
+}
+
 func TestValue(t *testing.T) {
 	t.Parallel()
 
@@ -582,6 +691,81 @@ func TestSetValue(t *testing.T) {
 	}
 }
 
+func TestFillForm(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "fillform.html")
+	defer cancel()
+
+	var res map[string]interface{}
+	var events string
+	if err := Run(ctx,
+		FillForm(`#form`, map[string]string{
+			"user":  "gopher",
+			"email": "gopher@example.com",
+			"City":  "Sydney",
+			"notes": "some notes",
+			"color": "blue",
+			"terms": "true",
+			"size":  "large",
+		}, ByID),
+		Evaluate(`(function(f) {
+			return {
+				user: f.user.value,
+				email: f.email.value,
+				city: f['city-input'].value,
+				notes: f.notes.value,
+				color: f.color.value,
+				terms: f.terms.checked,
+				size: f.size.value,
+			};
+		})(document.getElementById('form'))`, &res),
+		Text(`#events`, &events, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"user":  "gopher",
+		"email": "gopher@example.com",
+		"city":  "Sydney",
+		"notes": "some notes",
+		"color": "blue",
+		"terms": true,
+		"size":  "large",
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("expected %v, got: %v", want, res)
+	}
+	for _, name := range []string{"user", "email", "city-input", "notes", "color", "terms", "size"} {
+		if !strings.Contains(events, name+";") {
+			t.Errorf("expected a change event for %q, got events: %q", name, events)
+		}
+	}
+
+	err := Run(ctx, FillForm(`#form`, map[string]string{
+		"user":    "other",
+		"missing": "value",
+		"color":   "green",
+	}, ByID))
+	if err == nil {
+		t.Fatal("expected an error for unmatched fields")
+	}
+	for _, want := range []string{`no field matching "missing"`, `no option for "color" with value "green"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got: %v", want, err)
+		}
+	}
+
+	var user string
+	if err := Run(ctx, Value(`input[name="user"]`, &user, ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+	if user != "gopher" {
+		t.Errorf("expected the form to be left unmodified, got user %q", user)
+	}
+}
+
 func TestAttributes(t *testing.T) {
 	t.Parallel()
 
@@ -938,6 +1122,57 @@ func TestClick(t *testing.T) {
 	}
 }
 
+func TestClickAndWait(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`
+<a id="page" href="/page">page</a>
+<a id="file" href="/file">file</a>
+	`))
+	mux.Handle("/page", writeHTML(`<title>page</title>`))
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="file.pdf"`)
+		fmt.Fprint(w, "%PDF-1.4")
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "chromedp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		sel  string
+		want ClickOutcome
+	}{
+		{`#page`, ClickNavigated},
+		{`#file`, ClickDownloaded},
+	}
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, page.SetDownloadBehavior(page.SetDownloadBehaviorBehaviorAllow).WithDownloadPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		var outcome ClickOutcome
+		if err := Run(ctx,
+			Navigate(s.URL),
+			ClickAndWait(test.sel, &outcome, ByID),
+		); err != nil {
+			t.Fatalf("test %d got error: %v", i, err)
+		}
+		if outcome != test.want {
+			t.Errorf("test %d expected outcome %q, got: %q", i, test.want, outcome)
+		}
+	}
+}
+
 func TestDoubleClick(t *testing.T) {
 	t.Parallel()
 
@@ -975,6 +1210,60 @@ func TestDoubleClick(t *testing.T) {
 	}
 }
 
+func TestMiddleClick(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`
+<a id="link" href="/other">other</a>
+<script>
+	window.buttons = [];
+	document.getElementById('link').addEventListener('auxclick', function(e) {
+		window.buttons.push(e.button);
+	});
+</script>
+	`))
+	mux.Handle("/other", writeHTML(`<p>other</p>`))
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	ch := WaitNewTarget(ctx, func(info *target.Info) bool {
+		return info.Type == "page" && strings.HasSuffix(info.URL, "/other")
+	})
+	var buttons []int
+	var urlstr string
+	if err := Run(ctx,
+		MiddleClick(`#link`, ByID),
+		Evaluate(`window.buttons`, &buttons),
+		Location(&urlstr),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(buttons) != 1 || buttons[0] != 1 {
+		t.Errorf("expected an auxclick event for the middle button, got: %v", buttons)
+	}
+	if urlstr != s.URL+"/" {
+		t.Errorf("expected the current tab to stay on %q, got: %q", s.URL+"/", urlstr)
+	}
+
+	select {
+	case id := <-ch:
+		tabCtx, _ := NewContext(ctx, WithTargetID(id))
+		if err := Run(tabCtx); err != nil {
+			t.Fatal(err)
+		}
+		Cancel(tabCtx)
+	case <-time.After(10 * time.Second):
+		t.Error("expected the link to open in a new tab")
+	}
+}
+
 func TestSendKeys(t *testing.T) {
 	t.Parallel()
 
@@ -1063,6 +1352,47 @@ func TestScreenshot(t *testing.T) {
 	}
 }
 
+func TestScreenshotPadding(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<style>
+	body { margin: 0; height: 1000px; }
+	div { position: absolute; width: 100px; height: 50px; box-shadow: 0 0 10px black; }
+</style>
+<div id="corner" style="left: 0; top: 0"></div>
+<div id="middle" style="left: 200px; top: 200px"></div>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	tests := []struct {
+		sel           string
+		width, height int
+	}{
+		{`#middle`, 140, 90},
+		// clamped to the page's top left corner
+		{`#corner`, 120, 70},
+	}
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		var buf []byte
+		if err := Run(ctx, Screenshot(test.sel, &buf, ByQuery, Padding(20))); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size.X != test.width || size.Y != test.height {
+			t.Errorf("%s: expected dimensions to be %d*%d, got %d*%d",
+				test.sel, test.width, test.height, size.X, size.Y)
+		}
+	}
+}
+
 func TestScreenshotHighDPI(t *testing.T) {
 	t.Parallel()
 
@@ -1108,6 +1438,52 @@ func TestScreenshotHighDPI(t *testing.T) {
 	wantColor(295, 295, 0xffff, 0x0, 0x0, 0xffff)
 }
 
+func TestCompositionInput(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<meta charset="utf-8">
+<input id="input" type="text">
+<script>
+	window.events = [];
+	var input = document.getElementById('input');
+	['compositionstart', 'compositionupdate', 'compositionend', 'input'].forEach(function(typ) {
+		input.addEventListener(typ, function(e) {
+			window.events.push(typ + ':' + (e.data || ''));
+		});
+	});
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var value string
+	var events []string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		CompositionInput(`#input`, "日本", ByID),
+		Value(`#input`, &value, ByID),
+		Evaluate(`window.events`, &events),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "日本"; value != want {
+		t.Errorf("expected value %q, got: %q", want, value)
+	}
+	want := []string{
+		"compositionstart:",
+		"compositionupdate:日",
+		"compositionupdate:日本",
+		"input:日本",
+		"compositionend:日本",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("expected events %q, got: %q", want, events)
+	}
+}
+
 func TestSubmit(t *testing.T) {
 	t.Parallel()
 
@@ -1198,6 +1574,53 @@ func TestComputedStyle(t *testing.T) {
 	}
 }
 
+func TestPseudoStyle(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<style>
+	#icon::before { content: "\2605"; color: rgb(255, 0, 0); }
+	#icon::after { content: "after"; }
+</style>
+<span id="icon">star</span>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, after []*css.ComputedStyleProperty
+	if err := Run(ctx,
+		Navigate(s.URL),
+		PseudoStyle("#icon", "::before", &before, ByQuery),
+		PseudoStyle("#icon", "after", &after, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	value := func(style []*css.ComputedStyleProperty, name string) string {
+		for _, p := range style {
+			if p.Name == name {
+				return p.Value
+			}
+		}
+		return ""
+	}
+	if got, want := value(before, "content"), "\"\u2605\""; got != want {
+		t.Errorf("expected ::before content %s, got: %s", want, got)
+	}
+	if got, want := value(before, "color"), "rgb(255, 0, 0)"; got != want {
+		t.Errorf("expected ::before color %s, got: %s", want, got)
+	}
+	if got, want := value(after, "content"), `"after"`; got != want {
+		t.Errorf("expected ::after content %s, got: %s", want, got)
+	}
+
+	if err := Run(ctx, PseudoStyle("#icon", "first-line", &before, ByQuery)); err == nil {
+		t.Error("expected an error for a missing pseudo element")
+	}
+}
+
 func TestMatchedStyle(t *testing.T) {
 	t.Parallel()
 
@@ -1359,6 +1782,39 @@ func TestOuterHTML(t *testing.T) {
 	}
 }
 
+func TestOuterHTMLAll(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "table.html")
+	defer cancel()
+
+	tests := []struct {
+		sel string
+		by  QueryOption
+		exp int
+	}{
+		{`/html/body/table/tbody/tr`, BySearch, 3},
+		{`tbody tr`, ByQueryAll, 3},
+		{`tbody tr`, ByQuery, 1},
+		{`document.querySelector("#footer")`, ByJSPath, 1},
+	}
+	for i, test := range tests {
+		var html []string
+		if err := Run(ctx, OuterHTMLAll(test.sel, &html, test.by)); err != nil {
+			t.Fatalf("test %d got error: %v", i, err)
+		}
+
+		if len(html) != test.exp {
+			t.Fatalf("test %d expected %d results, got: %d", i, test.exp, len(html))
+		}
+		for j, h := range html {
+			if !strings.HasPrefix(h, "<tr") {
+				t.Errorf("test %d expected result %d to be a tr element, got: %q", i, j, h)
+			}
+		}
+	}
+}
+
 func TestScrollIntoView(t *testing.T) {
 	t.Parallel()
 
@@ -1384,6 +1840,41 @@ func TestScrollIntoView(t *testing.T) {
 	}
 }
 
+func TestPinIntoView(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<style>body { margin: 0; height: 5000px; }</style>
+<div id="target" style="position: absolute; top: 2000px">target</div>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	// scroll away, and wait for the scroll events to be handled
+	const scrollAway = `new Promise(function(resolve) {
+		window.scrollTo(0, 500);
+		requestAnimationFrame(function() {
+			requestAnimationFrame(function() { resolve(window.scrollY); });
+		});
+	})`
+	var pinned, released float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		PinIntoView("#target", ByQuery),
+		Evaluate(scrollAway, &pinned, evalAwaitPromise),
+		UnpinView(),
+		Evaluate(scrollAway, &released, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if pinned != 2000 {
+		t.Errorf("expected the window to stay pinned at 2000, got: %f", pinned)
+	}
+	if released != 500 {
+		t.Errorf("expected the window to scroll to 500 once released, got: %f", released)
+	}
+}
+
 func TestSVGFullXPath(t *testing.T) {
 	t.Parallel()
 
@@ -1471,3 +1962,770 @@ func TestWaitReadyReuseAction(t *testing.T) {
 		}
 	}
 }
+
+func TestInViewport(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<body style="margin: 0; height: 300vh">
+		<div id="top" style="position: absolute; top: 0; width: 100px; height: 100px"></div>
+		<div id="half" style="position: absolute; top: calc(100vh - 50px); width: 100px; height: 100px"></div>
+		<div id="below" style="position: absolute; top: 200vh; width: 100px; height: 100px"></div>
+	</body>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sel   string
+		ratio float64
+		want  bool
+	}{
+		{`#top`, 1, true},
+		{`#half`, 1, false},
+		{`#half`, 0.5, true},
+		{`#half`, 0.6, false},
+		{`#below`, 0.1, false},
+	}
+	for _, test := range tests {
+		var inside bool
+		if err := Run(ctx, InViewportRatio(test.sel, test.ratio, &inside, ByQuery)); err != nil {
+			t.Fatal(err)
+		}
+		if inside != test.want {
+			t.Errorf("expected %s with ratio %g to be inside: %t, got: %t", test.sel, test.ratio, test.want, inside)
+		}
+	}
+
+	var inside bool
+	if err := Run(ctx, InViewport(`#top`, &inside, ByQuery)); err != nil {
+		t.Fatal(err)
+	}
+	if !inside {
+		t.Errorf("expected #top to be inside the viewport")
+	}
+}
+
+func TestVisibilityRatio(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<body style="margin: 0; height: 300vh">
+		<div id="top" style="position: absolute; top: 0; width: 100px; height: 100px"></div>
+		<div id="half" style="position: absolute; top: calc(100vh - 50px); width: 100px; height: 100px"></div>
+		<div id="below" style="position: absolute; top: 200vh; width: 100px; height: 100px"></div>
+		<div style="position: absolute; top: 200px; width: 100px; height: 100px; overflow: hidden">
+			<div id="clipped" style="margin-top: 75px; height: 100px"></div>
+		</div>
+	</body>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sel  string
+		want float64
+	}{
+		{`#top`, 1},
+		{`#half`, 0.5},
+		{`#below`, 0},
+		{`#clipped`, 0.25},
+	}
+	for _, test := range tests {
+		var ratio float64
+		if err := Run(ctx, VisibilityRatio(test.sel, &ratio, ByQuery)); err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(ratio-test.want) > 0.01 {
+			t.Errorf("expected %s to have a visibility ratio of %g, got: %g", test.sel, test.want, ratio)
+		}
+	}
+}
+
+func TestWaitAnimationsFinished(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<style>
+	@keyframes enter { from { opacity: 0; } to { opacity: 1; } }
+	#card { animation: enter 300ms; }
+	#card span { transition: color 500ms; }
+	#spinner { animation: enter 100ms infinite; }
+</style>
+<div id="card"><span>card</span><i id="spinner"></i></div>
+<script>
+	window.started = performance.now();
+	requestAnimationFrame(function() {
+		document.querySelector('#card span').style.color = 'red';
+	});
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var elapsed float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitAnimationsFinished("#card", ByQuery),
+		Evaluate(`performance.now() - window.started`, &elapsed),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed < 500 {
+		t.Errorf("expected the transition to have finished, got: %vms", elapsed)
+	}
+}
+
+func TestNodeCount(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<html><head></head><body>
+		<ul id="list"><li>one</li><li>two</li><li>three</li></ul>
+		<p>text</p>
+	</body></html>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var total, list int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		NodeCount(&total),
+		SubtreeNodeCount(`#list`, &list, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	// html, head, body, ul, 3 li, and p
+	if total != 8 {
+		t.Errorf("expected 8 nodes, got: %d", total)
+	}
+	if list != 4 {
+		t.Errorf("expected 4 nodes in #list, got: %d", list)
+	}
+}
+
+func TestClickOpensTab(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testAllocate(t, "newtab.html")
+	defer cancel()
+
+	var tabCtx context.Context
+	var tabCancel context.CancelFunc
+	if err := Run(ctx, ClickOpensTab(`#new-tab`, regexp.MustCompile(`/form\.html$`), &tabCtx, &tabCancel, ByID)); err != nil {
+		t.Fatal(err)
+	}
+	defer tabCancel()
+
+	var urlstr string
+	if err := Run(tabCtx,
+		WaitVisible(`#form`, ByID),
+		Location(&urlstr),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(urlstr, "form.html") {
+		t.Errorf("expected to be on form.html, at %q", urlstr)
+	}
+}
+
+func TestResizeElement(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<textarea id="notes" style="box-sizing: content-box"></textarea>
+<script>
+	window.sizes = [];
+	new ResizeObserver(function(entries) {
+		var rect = entries[0].contentRect;
+		window.sizes.push(rect.width + 'x' + rect.height);
+	}).observe(document.querySelector('#notes'));
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var sizes []string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		ResizeElement("#notes", 300, 120, ByQuery),
+		Evaluate(`window.sizes`, &sizes),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) == 0 || sizes[len(sizes)-1] != "300x120" {
+		t.Errorf("expected the observer to be notified of 300x120, got: %q", sizes)
+	}
+}
+
+func TestContentQuads(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p style="width: 120px; font: 16px monospace">
+	some text before <a id="link" href="#">a link which wraps over several lines</a>
+</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var quads []dom.Quad
+	if err := Run(ctx,
+		Navigate(s.URL),
+		ContentQuads(`#link`, &quads, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(quads) < 2 {
+		t.Fatalf("expected a quad per line, got: %d", len(quads))
+	}
+	for i, q := range quads {
+		if len(q) != 8 {
+			t.Errorf("expected quad %d to have 8 coordinates, got: %v", i, q)
+		}
+	}
+}
+
+func TestWaitToast(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<div id="toasts"></div>
+<script>
+	setTimeout(function() {
+		var toast = document.createElement('div');
+		toast.className = 'toast';
+		toast.textContent = 'Saved!';
+		document.getElementById('toasts').appendChild(toast);
+		setTimeout(function() { toast.remove(); }, 500);
+	}, 100);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var text string
+	var count int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitToast(`.toast`, &text, ByQuery, ToastDismissed),
+		Evaluate(`document.querySelectorAll('.toast').length`, &count),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Saved!"; text != want {
+		t.Errorf("expected toast text %q, got: %q", want, text)
+	}
+	if count != 0 {
+		t.Errorf("expected the toast to have been dismissed, got %d toasts", count)
+	}
+}
+
+func TestWaitVisibleAnyFrame(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p>top</p>
+<iframe srcdoc="<iframe srcdoc='&lt;script&gt;setTimeout(function() { var b = document.createElement(&quot;button&quot;); b.id = &quot;nested&quot;; b.textContent = &quot;nested&quot;; document.body.appendChild(b); }, 100);&lt;/script&gt;'></iframe>"></iframe>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitVisibleAnyFrame(`#nested`),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	if err := Run(tctx, WaitVisibleAnyFrame(`#missing`)); err != context.DeadlineExceeded {
+		t.Errorf("expected a timeout for a missing element, got: %v", err)
+	}
+}
+
+func TestWaitTransitionStart(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<style>
+	#panel { opacity: 0; transition: opacity 1s; }
+	#panel.open { opacity: 1; }
+</style>
+<div id="panel">panel</div>
+<script>
+	setTimeout(function() {
+		document.getElementById('panel').className = 'open';
+	}, 200);
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitTransitionStart("#panel", "opacity", ByQuery),
+		// the transition is still running
+		WaitTransitionStart("#panel", "", ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tctx, tcancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer tcancel()
+	if err := Run(tctx, WaitTransitionStart("#panel", "width", ByQuery)); err != context.DeadlineExceeded {
+		t.Errorf("expected no width transition to start, got: %v", err)
+	}
+}
+
+func TestWaitMutations(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<ul id="list"></ul>
+<script>
+	var n = 0;
+	var timer = setInterval(function() {
+		var item = document.createElement('li');
+		item.textContent = 'item ' + (++n);
+		document.getElementById('list').appendChild(item);
+		if (n === 5) {
+			clearInterval(timer);
+		}
+	}, 100);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var before, after int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Evaluate(`document.getElementById('list').children.length`, &before),
+		WaitMutations(`#list`, 2, ByID),
+		Evaluate(`document.getElementById('list').children.length`, &after),
+	); err != nil {
+		t.Fatal(err)
+	}
+	// An item may be added between the count and the start of the
+	// observation, but not more, as items are added 100ms apart.
+	if added := after - before; added < 2 || added > 3 {
+		t.Errorf("expected 2 mutations, got %d items added", added)
+	}
+}
+
+func TestCanvasData(t *testing.T) {
+	t.Parallel()
+
+	images := httptest.NewServer(http.FileServer(http.Dir("testdata/images")))
+	defer images.Close()
+	s := httptest.NewServer(writeHTML(fmt.Sprintf(`
+<canvas id="chart" width="20" height="10"></canvas>
+<canvas id="tainted" width="20" height="10"></canvas>
+<script>
+	var ctx = document.getElementById('chart').getContext('2d');
+	ctx.fillStyle = 'red';
+	ctx.fillRect(0, 0, 20, 10);
+
+	var img = new Image();
+	img.onload = function() {
+		document.getElementById('tainted').getContext('2d').drawImage(img, 0, 0);
+		var p = document.createElement('p');
+		p.id = 'drawn';
+		p.textContent = 'drawn';
+		document.body.appendChild(p);
+	};
+	img.src = %q;
+</script>`, images.URL+"/github.png")))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var buf []byte
+	if err := Run(ctx,
+		Navigate(s.URL),
+		CanvasData(`#chart`, &buf, "image/png", ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 20 || size.Y != 10 {
+		t.Errorf("expected a 20x10 image, got: %dx%d", size.X, size.Y)
+	}
+	if r, g, b, a := img.At(5, 5).RGBA(); r != 0xffff || g != 0 || b != 0 || a != 0xffff {
+		t.Errorf("expected a red pixel, got: %v", img.At(5, 5))
+	}
+
+	err = Run(ctx,
+		WaitVisible(`#drawn`, ByID),
+		CanvasData(`#tainted`, &buf, "image/png", ByID),
+	)
+	if err == nil || !strings.Contains(err.Error(), "could not export canvas") {
+		t.Errorf("expected an error for a tainted canvas, got: %v", err)
+	}
+}
+
+func TestCSSVariables(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<style>
+	:root { --primary: #000; --spacing: 4px; }
+	.dark { --primary: #fff; }
+	@media screen {
+		.dark p { --accent: red; }
+	}
+</style>
+<div id="theme" style="--radius: 2px">
+	<p>themed</p>
+</div>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var light, dark map[string]string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		CSSVariables("#theme > p", &light, ByQuery),
+		SetAttributeValue("#theme", "class", "dark", ByQuery),
+		CSSVariables("#theme > p", &dark, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"--primary": "#000", "--spacing": "4px", "--radius": "2px"}; !reflect.DeepEqual(light, want) {
+		t.Errorf("expected %v, got: %v", want, light)
+	}
+	if want := map[string]string{"--primary": "#fff", "--spacing": "4px", "--radius": "2px", "--accent": "red"}; !reflect.DeepEqual(dark, want) {
+		t.Errorf("expected %v, got: %v", want, dark)
+	}
+}
+
+func TestUsedFonts(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<p id="text" style="font-family: 'chromedp missing font', monospace">chromedp</p>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var fonts []*css.PlatformFontUsage
+	if err := Run(ctx,
+		Navigate(s.URL),
+		UsedFonts(`#text`, &fonts, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(fonts) == 0 {
+		t.Fatal("expected at least one font")
+	}
+	var glyphs float64
+	for _, font := range fonts {
+		if font.FamilyName == "chromedp missing font" || font.IsCustomFont {
+			t.Errorf("expected a fallback platform font, got: %+v", font)
+		}
+		glyphs += font.GlyphCount
+	}
+	if glyphs != 8 {
+		t.Errorf("expected 8 glyphs, got: %v", glyphs)
+	}
+}
+
+func TestWaitAttributePresent(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<div id="app"></div>
+<script>
+	setTimeout(function() {
+		document.getElementById('app').setAttribute('data-hydrated', '');
+	}, 200);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var value string
+	var ok bool
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitAttributePresent(`#app`, "data-hydrated", ByID),
+		AttributeValue(`#app`, "data-hydrated", &value, &ok, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || value != "" {
+		t.Errorf("expected an empty data-hydrated attribute, got: %q (present: %t)", value, ok)
+	}
+}
+
+func TestStackingOrder(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<div id="static">static</div>
+<div style="position: relative; z-index: 1">
+	<div id="modal" style="position: absolute; z-index: 9999">modal</div>
+</div>
+<div id="backdrop" style="position: fixed; inset: 0; z-index: 1000"></div>
+<div style="opacity: 0.5">
+	<div id="faded" style="position: relative">faded</div>
+</div>
+<div id="below" style="position: relative; z-index: -1">below</div>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var order []int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		StackingOrder([]interface{}{`#modal`, `#backdrop`, `#static`, `#faded`, `#below`}, &order, ByID),
+	); err != nil {
+		t.Fatal(err)
+	}
+	// The modal's z-index only applies within its parent's stacking context,
+	// which is below the backdrop; the faded element is painted within the
+	// context created by its parent's opacity, which is painted like a
+	// positioned element with a z-index of 0.
+	if want := []int{1, 0, 3, 2, 4}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, got: %v", want, order)
+	}
+}
+
+func TestUniqueSelector(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<div id="menu">
+	<ul><li>a</li><li class="x">b</li></ul>
+</div>
+<div>
+	<ul><li>c</li><li>d</li></ul>
+	<span>e</span>
+</div>
+<p id="dup">f</p><p id="dup">g</p>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{`#menu`, `#menu`},
+		{`#menu li.x`, `#menu > ul > li:nth-child(2)`},
+		{`body > div:nth-child(2) li`, `div:nth-child(2) > ul > li:nth-child(1)`},
+		{`span`, `span`},
+		// duplicate IDs are not unique
+		{`p:last-of-type`, `p:nth-child(4)`},
+	}
+	for _, test := range tests {
+		var nodes []*cdp.Node
+		if err := Run(ctx, Nodes(test.sel, &nodes, ByQuery)); err != nil {
+			t.Fatal(err)
+		}
+		var sel string
+		var count int
+		if err := Run(ctx, UniqueSelector(nodes[0], &sel)); err != nil {
+			t.Fatalf("%s: %v", test.sel, err)
+		}
+		if sel != test.want {
+			t.Errorf("%s: expected %q, got: %q", test.sel, test.want, sel)
+		}
+		if err := Run(ctx, Evaluate(fmt.Sprintf(`document.querySelectorAll(%q).length`, sel), &count)); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("%s: expected %q to match one element, got: %d", test.sel, sel, count)
+		}
+	}
+}
+
+func TestWaitVisibleInContainer(t *testing.T) {
+	t.Parallel()
+
+	// a virtualized list, only rendering the items in view
+	s := httptest.NewServer(writeHTML(`
+<div id="list" style="height: 100px; overflow: auto; position: relative">
+	<div style="height: 4000px"></div>
+</div>
+<script>
+	var list = document.getElementById('list');
+	function render() {
+		list.querySelectorAll('p').forEach(function(p) { p.remove(); });
+		var first = Math.floor(list.scrollTop / 20);
+		for (var i = first; i < first + 5 && i < 200; i++) {
+			var p = document.createElement('p');
+			p.id = 'item-' + i;
+			p.textContent = 'item ' + i;
+			p.style = 'position: absolute; margin: 0; height: 20px; top: ' + (i * 20) + 'px';
+			list.appendChild(p);
+		}
+	}
+	list.addEventListener('scroll', render);
+	render();
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var text string
+	var windowY float64
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitVisibleInContainer(`#list`, `#item-150`, ByQuery),
+		Text(`#item-150`, &text, ByQuery),
+		Evaluate(`window.scrollY`, &windowY),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if text != "item 150" {
+		t.Errorf("expected the item to be rendered, got: %q", text)
+	}
+	if windowY != 0 {
+		t.Errorf("expected the window not to scroll, got: %f", windowY)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel2()
+	if err := Run(ctx2, WaitVisibleInContainer(`#list`, `#item-500`, ByQuery)); err == nil || err == context.DeadlineExceeded {
+		t.Errorf("expected an error once the end of the list is reached, got: %v", err)
+	}
+}
+
+func TestNodeByRole(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`
+<h1 id="title">Sign up</h1>
+<button id="cancel">Cancel</button>
+<div id="submit" role="button" aria-label="Submit">&rarr;</div>
+<button id="hidden" style="display: none">Submit</button>
+<script>
+	setTimeout(function() {
+		var b = document.createElement('button');
+		b.id = 'later';
+		b.textContent = 'Later';
+		document.body.appendChild(b);
+	}, 200);
+</script>
+	`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	if err := Run(ctx, Navigate(s.URL)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		role, name string
+		want       string
+	}{
+		{"heading", "", "title"},
+		{"button", "Submit", "submit"},
+		{"button", "Cancel", "cancel"},
+		{"button", "Later", "later"},
+	}
+	for _, test := range tests {
+		var node *cdp.Node
+		if err := Run(ctx, NodeByRole(test.role, test.name, &node)); err != nil {
+			t.Fatalf("%s %q: %v", test.role, test.name, err)
+		}
+		if id := node.AttributeValue("id"); id != test.want {
+			t.Errorf("%s %q: expected #%s, got: #%s", test.role, test.name, test.want, id)
+		}
+	}
+}
+
+func TestWaitComputedStyle(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<style>
+	#toast { opacity: 0; transition: opacity 300ms; }
+	#toast.shown { opacity: 1; }
+</style>
+<div id="toast">saved</div>
+<script>
+	setTimeout(function() {
+		document.getElementById('toast').className = 'shown';
+	}, 100);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var opacity string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitComputedStyle("#toast", "opacity", "1", ByQuery),
+		Evaluate(`getComputedStyle(document.getElementById('toast')).opacity`, &opacity),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if opacity != "1" {
+		t.Errorf("expected an opacity of 1, got: %q", opacity)
+	}
+}
+
+func TestWaitContentLoaded(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<div id="list"><div class="skeleton"></div><div class="skeleton"></div></div>
+<script>
+	setTimeout(function() {
+		var list = document.getElementById('list');
+		list.innerHTML = '<p class="item">first</p>';
+	}, 200);
+	setTimeout(function() {
+		document.getElementById('list').innerHTML += '<p class="item">second</p>';
+	}, 400);
+</script>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var count int
+	if err := Run(ctx,
+		Navigate(s.URL),
+		WaitContentLoaded(".skeleton", ".item", ByQuery),
+		Evaluate(`document.querySelectorAll('.skeleton').length`, &count),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the placeholders to be gone, got: %d", count)
+	}
+}
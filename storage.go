@@ -0,0 +1,200 @@
+package chromedp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/indexeddb"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/storage"
+)
+
+// CacheUsage is an action that retrieves the storage usage of origin, broken
+// down by storage type (eg, cache storage, IndexedDB, service workers).
+//
+// The origin should be in the form of "scheme://host[:port]", such as the
+// value of window.location.origin.
+func CacheUsage(origin string, usage *[]*storage.UsageForType) Action {
+	if usage == nil {
+		panic("usage cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		_, _, breakdown, err := storage.GetUsageAndQuota(origin).Do(ctx)
+		if err != nil {
+			return err
+		}
+		*usage = breakdown
+		return nil
+	})
+}
+
+// ClearCache is an action that clears the browser's HTTP cache.
+//
+// To clear the data stored by a specific origin, such as its cache storage,
+// use storage.ClearDataForOrigin.
+func ClearCache() Action {
+	return network.ClearBrowserCache()
+}
+
+// IndexedDBData is an action that retrieves all the records stored in the
+// objectStoreName object store of the databaseName IndexedDB database, which
+// belongs to securityOrigin. The record values are stored in values as JSON,
+// in key order.
+func IndexedDBData(securityOrigin, databaseName, objectStoreName string, values *[]json.RawMessage) Action {
+	if values == nil {
+		panic("values cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := indexeddb.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		const pageSize = 100
+		var res []json.RawMessage
+		for {
+			entries, hasMore, err := indexeddb.RequestData(securityOrigin, databaseName, objectStoreName, "", int64(len(res)), pageSize).Do(ctx)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				v, err := remoteObjectJSON(ctx, e.Value)
+				if err != nil {
+					return err
+				}
+				res = append(res, v)
+			}
+			if !hasMore || len(entries) == 0 {
+				break
+			}
+		}
+		*values = res
+		return nil
+	})
+}
+
+// remoteObjectJSON returns the JSON encoding of the value of a remote object,
+// releasing the object if it was held by reference.
+func remoteObjectJSON(ctx context.Context, o *runtime.RemoteObject) (json.RawMessage, error) {
+	if o.ObjectID == "" {
+		if o.Value == nil {
+			return json.RawMessage("null"), nil
+		}
+		return json.RawMessage(o.Value), nil
+	}
+	defer runtime.ReleaseObject(o.ObjectID).Do(ctx)
+
+	v, exp, err := runtime.CallFunctionOn(`function() { return this; }`).
+		WithObjectID(o.ObjectID).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exp != nil {
+		return nil, exp
+	}
+	return json.RawMessage(v.Value), nil
+}
+
+// CookiesSetDuring is an action that runs the during action, storing the
+// cookies of the browser which were set or changed in the meantime in out,
+// such as to verify the cookies set by a login flow. Cookies are identified by
+// their name, domain and path; a cookie is considered changed when any of its
+// other attributes, such as its value or expiry, differ.
+//
+// Note: cookies which were deleted in the meantime are not reported.
+func CookiesSetDuring(during Action, out *[]*network.Cookie) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		before, err := network.GetAllCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+		if err := during.Do(ctx); err != nil {
+			return err
+		}
+		after, err := network.GetAllCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		type key struct{ name, domain, path string }
+		prev := make(map[key]network.Cookie, len(before))
+		for _, c := range before {
+			prev[key{c.Name, c.Domain, c.Path}] = *c
+		}
+		var set []*network.Cookie
+		for _, c := range after {
+			if p, ok := prev[key{c.Name, c.Domain, c.Path}]; ok && p == *c {
+				continue
+			}
+			set = append(set, c)
+		}
+		*out = set
+		return nil
+	})
+}
+
+// ExpireCookies is an action that sets the expiry of the browser's cookies with
+// the specified names to the past, via network.SetCookies, which makes the
+// browser delete them, as it would when a session expires. With no names, all
+// the cookies are expired.
+//
+// Useful to test that a page handles a session expiring midway through a flow,
+// such as by redirecting to a login page. The other attributes of the cookies
+// are kept, so that the cookies being replaced are exactly the existing ones.
+func ExpireCookies(names ...string) Action {
+	return ActionFunc(func(ctx context.Context) error {
+		cookies, err := network.GetAllCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		expire := make(map[string]bool, len(names))
+		for _, name := range names {
+			expire[name] = true
+		}
+		expired := cdp.TimeSinceEpoch(time.Unix(1, 0))
+		var params []*network.CookieParam
+		for _, c := range cookies {
+			if len(names) > 0 && !expire[c.Name] {
+				continue
+			}
+			p := &network.CookieParam{
+				Name:     c.Name,
+				Value:    c.Value,
+				Path:     c.Path,
+				Secure:   c.Secure,
+				HTTPOnly: c.HTTPOnly,
+				SameSite: c.SameSite,
+				Expires:  &expired,
+				Priority: c.Priority,
+			}
+			if strings.HasPrefix(c.Domain, ".") {
+				p.Domain = c.Domain
+			} else {
+				// setting the domain would create a domain cookie,
+				// rather than replacing the host-only one
+				scheme := "http"
+				if c.Secure {
+					scheme = "https"
+				}
+				p.URL = scheme + "://" + c.Domain + c.Path
+			}
+			params = append(params, p)
+		}
+		if len(params) == 0 {
+			return nil
+		}
+		return network.SetCookies(params).Do(ctx)
+	})
+}
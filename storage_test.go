@@ -0,0 +1,178 @@
+package chromedp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+)
+
+func TestCacheUsage(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<title>cache</title>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res []byte
+	var usage []*storage.UsageForType
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Evaluate(`caches.open('test').then(function(cache) {
+			return cache.put('/data', new Response('x'.repeat(10000)));
+		})`, &res, evalAwaitPromise),
+		CacheUsage(s.URL, &usage),
+		ClearCache(),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, u := range usage {
+		if u.StorageType == storage.TypeCacheStorage {
+			found = true
+			if u.Usage <= 0 {
+				t.Errorf("expected cache storage usage to be positive, got: %v", u.Usage)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected cache storage usage, got: %v", usage)
+	}
+}
+
+func TestIndexedDBData(t *testing.T) {
+	t.Parallel()
+
+	s := httptest.NewServer(writeHTML(`<title>indexeddb</title>`))
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var res []byte
+	var values []json.RawMessage
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Evaluate(`new Promise(function(resolve, reject) {
+			var req = indexedDB.open('testdb', 1);
+			req.onupgradeneeded = function() {
+				req.result.createObjectStore('items', {keyPath: 'id'});
+			};
+			req.onerror = reject;
+			req.onsuccess = function() {
+				var tx = req.result.transaction('items', 'readwrite');
+				var store = tx.objectStore('items');
+				store.put({id: 1, name: 'foo'});
+				store.put({id: 2, name: 'bar', tags: ['a', 'b']});
+				tx.oncomplete = function() { resolve(true); };
+				tx.onerror = reject;
+			};
+		})`, &res, evalAwaitPromise),
+		IndexedDBData(s.URL, "testdb", "items", &values),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`{"id":1,"name":"foo"}`,
+		`{"id":2,"name":"bar","tags":["a","b"]}`,
+	}
+	if len(values) != len(want) {
+		t.Fatalf("expected %d values, got: %d", len(want), len(values))
+	}
+	for i, v := range values {
+		if string(v) != want[i] {
+			t.Errorf("expected value %d to be %s, got: %s", i, want[i], v)
+		}
+	}
+}
+
+func TestCookiesSetDuring(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "anonymous"})
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p>home</p>`))
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "gopher", HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "token"})
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p>logged in</p>`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var cookies []*network.Cookie
+	if err := Run(ctx,
+		Navigate(s.URL),
+		CookiesSetDuring(Navigate(s.URL+"/login"), &cookies),
+	); err != nil {
+		t.Fatal(err)
+	}
+	set := make(map[string]string)
+	for _, c := range cookies {
+		set[c.Name] = c.Value
+	}
+	// other tests may set cookies for the same host in the meantime
+	if set["session"] != "gopher" || set["csrf"] != "token" {
+		t.Errorf("expected the session and csrf cookies to be set, got: %v", set)
+	}
+	if _, ok := set["theme"]; ok {
+		t.Error("expected the unchanged theme cookie not to be reported")
+	}
+}
+
+func TestExpireCookies(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "expire-session", Value: "gopher", HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: "expire-theme", Value: "dark"})
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<p>home</p>`))
+	})
+	mux.HandleFunc("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{"expire-session", "expire-theme"} {
+			if c, err := r.Cookie(name); err == nil {
+				w.Write([]byte(c.Name + "=" + c.Value + ";"))
+			}
+		}
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	const whoami = `fetch('/whoami').then(function(r) { return r.text(); })`
+	var before, after string
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Evaluate(whoami, &before, evalAwaitPromise),
+		ExpireCookies("expire-session"),
+		Evaluate(whoami, &after, evalAwaitPromise),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := "expire-session=gopher;expire-theme=dark;"; before != want {
+		t.Errorf("expected cookies %q, got: %q", want, before)
+	}
+	if want := "expire-theme=dark;"; after != want {
+		t.Errorf("expected cookies %q after expiring the session, got: %q", want, after)
+	}
+}
@@ -0,0 +1,106 @@
+package chromedp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/target"
+)
+
+// Tabs is a set of tabs identified by name, which share the same browser
+// context, and thus the same cookies and storage. It is created via WithTabs.
+//
+// Useful to script workflows spanning multiple tabs, such as signing in via
+// one tab and checking a notification in another, without keeping track of
+// the chromedp context of each tab.
+type Tabs struct {
+	parent           context.Context
+	browserContextID cdp.BrowserContextID
+
+	mu   sync.Mutex
+	tabs map[string]tab
+}
+
+// tab is a tab of a Tabs set.
+type tab struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// WithTabs creates a new browser context in the browser of the chromedp
+// context ctx, allocating the browser if needed, and returns a set of named
+// tabs within it.
+//
+// The tabs are opened on first use, and share their cookies and storage with
+// each other, but not with the tabs outside of the set. Cancel should be called
+// once the tabs are no longer needed.
+func WithTabs(ctx context.Context) (*Tabs, error) {
+	// allocate the browser, if needed
+	if err := Run(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := target.CreateBrowserContext().Do(cdp.WithExecutor(ctx, FromContext(ctx).Browser))
+	if err != nil {
+		return nil, err
+	}
+	return &Tabs{
+		parent:           ctx,
+		browserContextID: id,
+		tabs:             make(map[string]tab),
+	}, nil
+}
+
+// Context returns the chromedp context of the named tab, opening a new blank
+// tab if there's no tab with that name yet.
+func (t *Tabs) Context(name string) (context.Context, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tab, ok := t.tabs[name]; ok {
+		return tab.ctx, nil
+	}
+
+	id, err := target.CreateTarget("about:blank").
+		WithBrowserContextID(t.browserContextID).
+		Do(cdp.WithExecutor(t.parent, FromContext(t.parent).Browser))
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := NewContext(t.parent, WithTargetID(id))
+	t.tabs[name] = tab{ctx, cancel}
+	return ctx, nil
+}
+
+// RunIn runs the actions against the named tab, opening it if needed, as with
+// Run.
+func (t *Tabs) RunIn(name string, actions ...Action) error {
+	ctx, err := t.Context(name)
+	if err != nil {
+		return err
+	}
+	return Run(ctx, actions...)
+}
+
+// Cancel closes the tabs, and then disposes of their browser context,
+// returning the first error encountered.
+func (t *Tabs) Cancel() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var err error
+	for name, tab := range t.tabs {
+		if cerr := Cancel(tab.ctx); err == nil {
+			err = cerr
+		}
+		tab.cancel()
+		delete(t.tabs, name)
+	}
+
+	action := target.DisposeBrowserContext(t.browserContextID)
+	if derr := action.Do(cdp.WithExecutor(t.parent, FromContext(t.parent).Browser)); err == nil {
+		err = derr
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package chromedp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTabs(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "tabs-session", Value: "gopher"})
+		w.Write([]byte(`<p>signed in</p>`))
+	})
+	mux.HandleFunc("/inbox", func(w http.ResponseWriter, r *http.Request) {
+		user := "anonymous"
+		if c, err := r.Cookie("tabs-session"); err == nil {
+			user = c.Value
+		}
+		w.Write([]byte(`<p id="user">` + user + `</p>`))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	tabs, err := WithTabs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var inbox, outside string
+	if err := tabs.RunIn("login", Navigate(s.URL+"/login")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tabs.RunIn("inbox",
+		Navigate(s.URL+"/inbox"),
+		Text("#user", &inbox, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(ctx,
+		Navigate(s.URL+"/inbox"),
+		Text("#user", &outside, ByQuery),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if inbox != "gopher" {
+		t.Errorf("expected the session to carry over to the inbox tab, got: %q", inbox)
+	}
+	if outside != "anonymous" {
+		t.Errorf("expected the session not to leak outside of the tabs, got: %q", outside)
+	}
+
+	login, err := tabs.Context("login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var url string
+	if err := Run(login, Location(&url)); err != nil {
+		t.Fatal(err)
+	}
+	if want := s.URL + "/login"; url != want {
+		t.Errorf("expected the login tab to be reused at %q, got: %q", want, url)
+	}
+
+	if err := tabs.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if login.Err() == nil {
+		t.Error("expected the context of the login tab to be cancelled")
+	}
+}
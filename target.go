@@ -2,7 +2,10 @@ package chromedp
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -11,6 +14,8 @@ import (
 	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/target"
 )
@@ -33,6 +38,92 @@ type Target struct {
 	cur   *cdp.Frame
 	curMu sync.RWMutex
 
+	// requests is the requests that haven't finished loading yet, by ID, and
+	// responses is the last maxResponses requests which finished loading,
+	// oldest first. Both are recorded while the Network domain is enabled.
+	// networkSeq is used to keep track of the order of the network events.
+	requests   map[network.RequestID]*trackedRequest
+	responses  []*trackedRequest
+	networkSeq int64
+	networkMu  sync.RWMutex
+
+	// redirectChain is the list of URLs requested by the last top-level
+	// navigation, starting with the original URL and followed by the target
+	// of each redirect. Also recorded while the Network domain is enabled.
+	redirectChain []string
+
+	// zoom is the state of the last SetZoom action, used to undo it before
+	// applying a new zoom factor. emulationMu guards it, and is held by the
+	// actions using it for as long as they run.
+	zoom        zoomState
+	emulationMu sync.Mutex
+
+	// profile is the EmulationProfile applied by the last ApplyProfile
+	// action, used to revert its settings via ClearEmulation. It is
+	// guarded by emulationMu.
+	profile *EmulationProfile
+
+	// cookies is the state of the last SetCookiesEnabled action, used to
+	// undo it on the next call. It is guarded by emulationMu.
+	cookies cookiesState
+
+	// saveDataScript is the script added by the last SetSaveData action,
+	// removed on the next call. It is guarded by emulationMu.
+	saveDataScript page.ScriptIdentifier
+
+	// clientHints is the state of the last SetClientHints action, whose
+	// script is replaced on the next call. It is guarded by emulationMu.
+	clientHints clientHintsState
+
+	// windowOpen is the state of the last HandleWindowOpen action, replaced
+	// on the next call. It is guarded by emulationMu.
+	windowOpen windowOpenState
+
+	// onlineScript is the script added by the last SetOnline action,
+	// replaced on the next call. It is guarded by emulationMu.
+	onlineScript page.ScriptIdentifier
+
+	// permissionScripts are the scripts added by the last SetPermissionState
+	// action for each permission name, replaced on the next call for the
+	// same name. It is guarded by emulationMu.
+	permissionScripts map[string]page.ScriptIdentifier
+
+	// matchMediaScripts are the scripts added by the last OverrideMatchMedia
+	// action for each media query, replaced on the next call for the same
+	// query. It is guarded by emulationMu.
+	matchMediaScripts map[string]page.ScriptIdentifier
+
+	// hardwareConcurrencyScript is the script added by the last
+	// SetHardwareConcurrency action, replaced on the next call. It is
+	// guarded by emulationMu.
+	hardwareConcurrencyScript page.ScriptIdentifier
+
+	// smoothScrollScript is the script added by DisableSmoothScroll, so that
+	// it's only added once. It is guarded by emulationMu.
+	smoothScrollScript page.ScriptIdentifier
+
+	// noTranslateScript is the script added by SuppressInfobars, so that it's
+	// only added once. It is guarded by emulationMu.
+	noTranslateScript page.ScriptIdentifier
+
+	// extraHeaders are the extra HTTP headers set on the target by actions
+	// such as SetSaveData, so that each action only adds or removes its own
+	// headers. It is guarded by emulationMu.
+	extraHeaders map[string]interface{}
+
+	// ctx is the context the target was attached with, which is done once
+	// the target is gone.
+	ctx context.Context
+
+	// handlers is the list of request handlers registered via intercept, in
+	// registration order. interceptOnce installs the listener dispatching the
+	// paused requests to them, and fetchMu serializes the updates of the
+	// Fetch domain's patterns.
+	handlers      []*requestHandler
+	interceptMu   sync.Mutex
+	interceptOnce sync.Once
+	fetchMu       sync.Mutex
+
 	// logging funcs
 	logf, errf func(string, ...interface{})
 
@@ -80,7 +171,7 @@ func (t *Target) run(ctx context.Context) {
 				t.listenersMu.Unlock()
 
 				switch msg.Method.Domain() {
-				case "Page", "DOM":
+				case "Page", "DOM", "Network":
 					select {
 					case <-ctx.Done():
 						return
@@ -101,6 +192,8 @@ func (t *Target) run(ctx context.Context) {
 				t.pageEvent(ev.value)
 			case "DOM":
 				t.domEvent(ctx, ev.value)
+			case "Network":
+				t.networkEvent(ev.value)
 			}
 		}
 	}
@@ -338,3 +431,393 @@ func (t *Target) domEvent(ctx context.Context, ev interface{}) {
 	op(n)
 	f.Unlock()
 }
+
+// maxResponses is the number of loaded responses kept track of by a target.
+const maxResponses = 100
+
+// trackedRequest is a request made by a target, along with its response.
+type trackedRequest struct {
+	// sent and received are the sequence numbers of the request's
+	// EventRequestWillBeSent and EventResponseReceived events. sent is zero
+	// for requests which were sent before the Network domain was enabled.
+	sent, received int64
+
+	// res is the response of the request, which is nil until it's received.
+	res *network.EventResponseReceived
+
+	// rawHeaders is the raw headers of the response, as received over the
+	// wire, including the Set-Cookie headers.
+	rawHeaders network.Headers
+
+	// loaded is set once the body of the response has finished loading.
+	loaded bool
+}
+
+// networkEvent handles incoming network events.
+func (t *Target) networkEvent(ev interface{}) {
+	t.networkMu.Lock()
+	defer t.networkMu.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		t.networkSeq++
+		// Redirects reuse the ID of the request, so this also drops the
+		// redirect response and its raw headers.
+		t.requests[e.RequestID] = &trackedRequest{sent: t.networkSeq}
+		// Navigation requests share their ID with the loader.
+		if e.RequestID == network.RequestID(e.LoaderID) && e.FrameID == t.topFrameID() {
+			if e.RedirectResponse == nil {
+				t.redirectChain = nil
+			}
+			t.redirectChain = append(t.redirectChain, e.Request.URL)
+		}
+
+	case *network.EventResponseReceived:
+		t.networkSeq++
+		req := t.requests[e.RequestID]
+		if req == nil {
+			req = &trackedRequest{}
+			t.requests[e.RequestID] = req
+		}
+		req.received, req.res = t.networkSeq, e
+
+	case *network.EventResponseReceivedExtraInfo:
+		if req := t.requests[e.RequestID]; req != nil {
+			req.rawHeaders = e.Headers
+		}
+
+	case *network.EventLoadingFinished:
+		req := t.requests[e.RequestID]
+		delete(t.requests, e.RequestID)
+		if req == nil || req.res == nil {
+			return
+		}
+		req.loaded = true
+		t.responses = append(t.responses, req)
+		if len(t.responses) > maxResponses {
+			t.responses = t.responses[len(t.responses)-maxResponses:]
+		}
+
+	case *network.EventLoadingFailed:
+		// This includes the requests which were cancelled.
+		delete(t.requests, e.RequestID)
+	}
+}
+
+// inflightRequests returns the number of requests that haven't finished
+// loading yet.
+func (t *Target) inflightRequests() int {
+	t.networkMu.RLock()
+	defer t.networkMu.RUnlock()
+	return len(t.requests)
+}
+
+// lastNetworkSeq returns the sequence number of the last network event.
+func (t *Target) lastNetworkSeq() int64 {
+	t.networkMu.RLock()
+	defer t.networkMu.RUnlock()
+	return t.networkSeq
+}
+
+// lastResponse returns a copy of the last request matching fn whose response
+// was received, or nil if there's none.
+func (t *Target) lastResponse(fn func(*trackedRequest) bool) *trackedRequest {
+	t.networkMu.RLock()
+	defer t.networkMu.RUnlock()
+
+	var last *trackedRequest
+	check := func(req *trackedRequest) {
+		if req.res != nil && (last == nil || req.received > last.received) && fn(req) {
+			last = req
+		}
+	}
+	for _, req := range t.requests {
+		check(req)
+	}
+	for _, req := range t.responses {
+		check(req)
+	}
+	if last == nil {
+		return nil
+	}
+	req := *last
+	return &req
+}
+
+// topFrameID returns the ID of the current top level frame.
+func (t *Target) topFrameID() cdp.FrameID {
+	t.curMu.RLock()
+	defer t.curMu.RUnlock()
+	if t.cur == nil {
+		return ""
+	}
+	return t.cur.ID
+}
+
+// lastRedirectChain returns a copy of the redirect chain of the last top-level
+// navigation.
+func (t *Target) lastRedirectChain() []string {
+	t.networkMu.RLock()
+	defer t.networkMu.RUnlock()
+	return append([]string(nil), t.redirectChain...)
+}
+
+// requestHandler handles some of the requests paused by the Fetch domain.
+type requestHandler struct {
+	// stage is the stage at which the handler handles requests.
+	stage fetch.RequestStage
+
+	// match returns whether the handler handles the paused request. It's
+	// called synchronously while handling events, so it must not block.
+	match func(*fetch.EventRequestPaused) bool
+
+	// fulfill resolves the paused request on its own, such as by fulfilling
+	// it with a mocked response. Only one live handler per stage may set
+	// it, and it takes precedence over the handlers modifying the request,
+	// which are skipped. The request is continued unmodified if fulfill
+	// returns an error.
+	fulfill func(context.Context, *fetch.EventRequestPaused) error
+
+	// modify modifies the paused request, such as by rewriting its headers
+	// or holding it for a while. The modifications of all the matching
+	// handlers are applied in registration order, and are then merged into
+	// a single command resolving the request. An error only skips the rest
+	// of the handler.
+	modify func(context.Context, *pausedRequest) error
+
+	// ctx is the context the handler was registered with.
+	ctx context.Context
+}
+
+// pausedRequest is a request paused by the Fetch domain, holding the
+// modifications of the handlers matching it until it's resolved.
+type pausedRequest struct {
+	*fetch.EventRequestPaused
+
+	// headers are the modified request or response headers, depending on
+	// the stage, or nil if they weren't modified.
+	headers []*fetch.HeaderEntry
+
+	// body is the response body, once retrieved via responseBody.
+	body    []byte
+	hasBody bool
+}
+
+// isResponse returns whether the request is paused at the response stage.
+func (p *pausedRequest) isResponse() bool {
+	return p.ResponseStatusCode != 0 || p.ResponseErrorReason != ""
+}
+
+// headerEntries returns the current headers of the request or response.
+func (p *pausedRequest) headerEntries() []*fetch.HeaderEntry {
+	if p.headers != nil {
+		return p.headers
+	}
+	if p.isResponse() {
+		return append([]*fetch.HeaderEntry(nil), p.ResponseHeaders...)
+	}
+	var orig map[string]string
+	_ = json.Unmarshal(p.Request.Headers, &orig)
+	headers := make([]*fetch.HeaderEntry, 0, len(orig))
+	for name, value := range orig {
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: value})
+	}
+	return headers
+}
+
+// header returns the value of the current header with the name, if any.
+func (p *pausedRequest) header(name string) (string, bool) {
+	for _, h := range p.headerEntries() {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+// setHeader replaces the headers with the name by a single one with the
+// value.
+func (p *pausedRequest) setHeader(name, value string) {
+	p.delHeader(name)
+	p.headers = append(p.headerEntries(), &fetch.HeaderEntry{Name: name, Value: value})
+}
+
+// delHeader removes the headers with the name, leaving the headers
+// unmodified if there are none.
+func (p *pausedRequest) delHeader(name string) {
+	entries := p.headerEntries()
+	headers := make([]*fetch.HeaderEntry, 0, len(entries))
+	for _, h := range entries {
+		if !strings.EqualFold(h.Name, name) {
+			headers = append(headers, h)
+		}
+	}
+	if len(headers) < len(entries) {
+		p.headers = headers
+	}
+}
+
+// responseBody returns the body of the paused response, retrieving it only
+// once for all the handlers.
+func (p *pausedRequest) responseBody(ctx context.Context) ([]byte, error) {
+	if !p.hasBody {
+		body, err := fetch.GetResponseBody(p.RequestID).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.body, p.hasBody = body, true
+	}
+	return p.body, nil
+}
+
+// resolve continues the request with the modified headers, or fulfills the
+// response with them.
+func (p *pausedRequest) resolve(ctx context.Context) error {
+	switch {
+	case p.headers == nil:
+		return fetch.ContinueRequest(p.RequestID).Do(ctx)
+	case !p.isResponse():
+		return fetch.ContinueRequest(p.RequestID).WithHeaders(p.headers).Do(ctx)
+	}
+	body, err := p.responseBody(ctx)
+	if err != nil {
+		return err
+	}
+	return fetch.FulfillRequest(p.RequestID, p.ResponseStatusCode).
+		WithResponseHeaders(p.headers).
+		WithBody(base64.StdEncoding.EncodeToString(body)).
+		Do(ctx)
+}
+
+// intercept registers h on the current target until ctx is done, enabling
+// the Fetch domain with the patterns needed by all the registered handlers.
+//
+// A paused request is fulfilled by the handler matching it which fulfills
+// requests, if any. Otherwise, the modifications of all the handlers matching
+// it are merged, so that a target's requests are only ever resolved once. An
+// error is returned if h fulfills requests, and another live handler for the
+// same stage already does.
+func intercept(ctx context.Context, h *requestHandler) error {
+	t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+	if !ok {
+		return ErrInvalidTarget
+	}
+	t.interceptOnce.Do(func() {
+		t.listenersMu.Lock()
+		t.listeners = append(t.listeners, cancelableListener{t.ctx, t.requestPaused})
+		t.listenersMu.Unlock()
+	})
+
+	h.ctx = ctx
+	t.interceptMu.Lock()
+	if h.fulfill != nil {
+		for _, other := range t.handlers {
+			if other.stage == h.stage && other.fulfill != nil && other.ctx.Err() == nil {
+				t.interceptMu.Unlock()
+				return errors.New("another action is already fulfilling the requests of the target")
+			}
+		}
+	}
+	t.handlers = append(t.handlers, h)
+	t.interceptMu.Unlock()
+
+	if err := t.syncFetchPatterns(ctx); err != nil {
+		t.removeHandler(h)
+		return err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-t.ctx.Done():
+			return
+		}
+		t.removeHandler(h)
+		_ = t.syncFetchPatterns(cdp.WithExecutor(t.ctx, t))
+	}()
+	return nil
+}
+
+// removeHandler unregisters h.
+func (t *Target) removeHandler(h *requestHandler) {
+	t.interceptMu.Lock()
+	defer t.interceptMu.Unlock()
+	for i, other := range t.handlers {
+		if other == h {
+			t.handlers = append(t.handlers[:i], t.handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// syncFetchPatterns enables the Fetch domain with the patterns needed by the
+// registered handlers, or disables it if there are none.
+func (t *Target) syncFetchPatterns(ctx context.Context) error {
+	t.fetchMu.Lock()
+	defer t.fetchMu.Unlock()
+
+	stages := make(map[fetch.RequestStage]bool)
+	t.interceptMu.Lock()
+	for _, h := range t.handlers {
+		stages[h.stage] = true
+	}
+	t.interceptMu.Unlock()
+
+	var patterns []*fetch.RequestPattern
+	for _, stage := range []fetch.RequestStage{fetch.RequestStageRequest, fetch.RequestStageResponse} {
+		if stages[stage] {
+			patterns = append(patterns, &fetch.RequestPattern{URLPattern: "*", RequestStage: stage})
+		}
+	}
+	if len(patterns) == 0 {
+		return fetch.Disable().Do(ctx)
+	}
+	return fetch.Enable().WithPatterns(patterns).Do(ctx)
+}
+
+// requestPaused dispatches a paused request to the registered handlers
+// matching it.
+func (t *Target) requestPaused(ev interface{}) {
+	e, ok := ev.(*fetch.EventRequestPaused)
+	if !ok {
+		return
+	}
+	p := &pausedRequest{EventRequestPaused: e}
+	stage := fetch.RequestStageRequest
+	if p.isResponse() {
+		stage = fetch.RequestStageResponse
+	}
+
+	var fulfiller *requestHandler
+	var modifiers []*requestHandler
+	t.interceptMu.Lock()
+	for _, h := range t.handlers {
+		if h.stage != stage || h.ctx.Err() != nil || !h.match(e) {
+			continue
+		}
+		if h.fulfill != nil {
+			fulfiller = h
+		} else {
+			modifiers = append(modifiers, h)
+		}
+	}
+	t.interceptMu.Unlock()
+
+	go func() {
+		ctx := cdp.WithExecutor(t.ctx, t)
+		if fulfiller != nil {
+			if fulfiller.fulfill(fulfiller.ctx, e) == nil {
+				return
+			}
+		} else {
+			for _, h := range modifiers {
+				_ = h.modify(h.ctx, p)
+			}
+			if p.resolve(ctx) == nil {
+				return
+			}
+		}
+		// Errors here mean the target has gone away, so there is
+		// nobody left to report them to.
+		_ = fetch.ContinueRequest(e.RequestID).Do(ctx)
+	}()
+}
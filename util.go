@@ -1,11 +1,18 @@
 package chromedp
 
 import (
+	"context"
+	"encoding/json"
 	"net"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/chromedp/cdproto"
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
 )
 
 // forceIP tries to force the host component in urlstr to be an IP address.
@@ -29,6 +36,98 @@ func forceIP(urlstr string) string {
 	return u.String()
 }
 
+// waitFor calls check every interval until it returns true or an error, or
+// until ctx is done.
+func waitFor(ctx context.Context, interval time.Duration, check func(context.Context) (bool, error)) error {
+	t := time.NewTimer(0)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		ok, err := check(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		t.Reset(interval)
+	}
+}
+
+// evaluateNodes evaluates the Javascript expression, which must produce an
+// array of elements, returning the nodes of the elements in order.
+func evaluateNodes(ctx context.Context, expression string) ([]*cdp.Node, error) {
+	var arr *runtime.RemoteObject
+	if err := EvaluateAsDevTools(expression, &arr).Do(ctx); err != nil {
+		return nil, err
+	}
+	if arr.ObjectID == "" {
+		return nil, nil
+	}
+	props, _, _, exp, err := runtime.GetProperties(arr.ObjectID).WithOwnProperties(true).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exp != nil {
+		return nil, exp
+	}
+
+	ids := make([]cdp.NodeID, len(props))
+	n := 0
+	for _, prop := range props {
+		i, err := strconv.Atoi(prop.Name)
+		if err != nil || i < 0 || i >= len(ids) || prop.Value == nil || prop.Value.ObjectID == "" {
+			continue
+		}
+		if ids[i], err = dom.RequestNode(prop.Value.ObjectID).Do(ctx); err != nil {
+			return nil, err
+		}
+		if i >= n {
+			n = i + 1
+		}
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	var nodes []*cdp.Node
+	if err := Nodes(ids[:n], &nodes, ByNodeID).Do(ctx); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// remoteObjectString returns a human readable representation of a remote
+// object, such as a console API call argument.
+func remoteObjectString(o *runtime.RemoteObject) string {
+	if o.Value != nil {
+		var s string
+		if err := json.Unmarshal(o.Value, &s); err == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return o.Type.String()
+}
+
+// exceptionString returns a human readable representation of an exception,
+// such as "Uncaught Error: message".
+func exceptionString(e *runtime.ExceptionDetails) string {
+	if e.Exception == nil {
+		return e.Text
+	}
+	// only keep the first line, and not the stack trace
+	desc := strings.SplitN(remoteObjectString(e.Exception), "\n", 2)[0]
+	return e.Text + " " + desc
+}
+
 func runListeners(list []cancelableListener, ev interface{}) []cancelableListener {
 	for i := 0; i < len(list); {
 		listener := list[i]
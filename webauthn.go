@@ -0,0 +1,72 @@
+package chromedp
+
+import (
+	"context"
+
+	"github.com/chromedp/cdproto/webauthn"
+)
+
+// AddVirtualAuthenticator is an action that enables the WebAuthn domain, and
+// adds a virtual authenticator with the specified options, storing its ID in
+// id. Virtual authenticators allow automating Web Authentication (eg, passkey)
+// flows, which otherwise require user interaction with a physical
+// authenticator.
+//
+// When opts is nil, a CTAP2 internal authenticator with resident key support
+// and successful user verification is added.
+func AddVirtualAuthenticator(opts *webauthn.VirtualAuthenticatorOptions, id *webauthn.AuthenticatorID) Action {
+	if id == nil {
+		panic("id cannot be nil")
+	}
+	if opts == nil {
+		opts = &webauthn.VirtualAuthenticatorOptions{
+			Protocol:            webauthn.AuthenticatorProtocolCtap2,
+			Transport:           webauthn.AuthenticatorTransportInternal,
+			HasResidentKey:      true,
+			HasUserVerification: true,
+			IsUserVerified:      true,
+		}
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		if err := webauthn.Enable().Do(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		*id, err = webauthn.AddVirtualAuthenticator(opts).Do(ctx)
+		return err
+	})
+}
+
+// RemoveVirtualAuthenticator is an action that removes the virtual
+// authenticator with the specified ID, along with all of its credentials.
+func RemoveVirtualAuthenticator(id webauthn.AuthenticatorID) Action {
+	return webauthn.RemoveVirtualAuthenticator(id)
+}
+
+// AddCredential is an action that adds a credential to the virtual
+// authenticator with the specified ID.
+//
+// Note: the credential's RpID must be set, and its PrivateKey must be a
+// base64-encoded ECDSA P-256 private key in PKCS#8 format.
+func AddCredential(id webauthn.AuthenticatorID, credential *webauthn.Credential) Action {
+	if credential == nil {
+		panic("credential cannot be nil")
+	}
+	return webauthn.AddCredential(id, credential)
+}
+
+// Credentials is an action that retrieves all the credentials stored by the
+// virtual authenticator with the specified ID.
+func Credentials(id webauthn.AuthenticatorID, credentials *[]*webauthn.Credential) Action {
+	if credentials == nil {
+		panic("credentials cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		var err error
+		*credentials, err = webauthn.GetCredentials(id).Do(ctx)
+		return err
+	})
+}
@@ -0,0 +1,59 @@
+package chromedp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+
+	"github.com/chromedp/cdproto/webauthn"
+)
+
+func TestVirtualAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := base64.StdEncoding.EncodeToString([]byte("credential-1"))
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var id webauthn.AuthenticatorID
+	var credentials []*webauthn.Credential
+	if err := Run(ctx, AddVirtualAuthenticator(nil, &id)); err != nil {
+		t.Fatal(err)
+	}
+	if id == "" {
+		t.Fatal("expected an authenticator ID")
+	}
+
+	// id is only known after the authenticator is added
+	if err := Run(ctx,
+		AddCredential(id, &webauthn.Credential{
+			CredentialID:         credentialID,
+			IsResidentCredential: true,
+			RpID:                 "example.com",
+			PrivateKey:           base64.StdEncoding.EncodeToString(pkcs8),
+			UserHandle:           base64.StdEncoding.EncodeToString([]byte("gopher")),
+		}),
+		Credentials(id, &credentials),
+		RemoveVirtualAuthenticator(id),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 1 {
+		t.Fatalf("expected one credential, got: %d", len(credentials))
+	}
+	if got := credentials[0].CredentialID; got != credentialID {
+		t.Errorf("expected credential ID %q, got: %q", credentialID, got)
+	}
+}
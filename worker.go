@@ -0,0 +1,143 @@
+package chromedp
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/serviceworker"
+)
+
+// WorkerInfo holds information about a worker used by a page.
+type WorkerInfo struct {
+	// Type is the type of the worker, one of "worker", "shared_worker" or
+	// "service_worker".
+	Type string
+
+	// URL is the URL of the script of the worker.
+	URL string
+
+	// VersionID, Status and RunningStatus are the version of a service
+	// worker, and its state, such as "activated" and "running". They are
+	// only set for service workers.
+	VersionID     string
+	Status        serviceworker.VersionStatus
+	RunningStatus serviceworker.VersionRunningStatus
+}
+
+// Workers is an action that retrieves the workers used by the current page,
+// storing them in out, sorted by type and URL.
+//
+// Service workers are retrieved via the ServiceWorker domain; the ones
+// controlling the page, or registered for a scope the page is in, are
+// included, except for redundant versions. Dedicated and shared workers are the
+// ones listed by target.GetTargets, with the same origin as the page.
+func Workers(out *[]WorkerInfo) Action {
+	if out == nil {
+		panic("out cannot be nil")
+	}
+
+	return ActionFunc(func(ctx context.Context) error {
+		t, ok := cdp.ExecutorFromContext(ctx).(*Target)
+		if !ok {
+			return ErrInvalidTarget
+		}
+		var urlstr string
+		if err := Location(&urlstr).Do(ctx); err != nil {
+			return err
+		}
+
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var mu sync.Mutex
+		scopes := make(map[serviceworker.RegistrationID]string)
+		versions := make(map[string]*serviceworker.Version)
+		ListenTarget(lctx, func(ev interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch e := ev.(type) {
+			case *serviceworker.EventWorkerRegistrationUpdated:
+				for _, r := range e.Registrations {
+					scopes[r.RegistrationID] = r.ScopeURL
+					if r.IsDeleted {
+						delete(scopes, r.RegistrationID)
+					}
+				}
+			case *serviceworker.EventWorkerVersionUpdated:
+				for _, v := range e.Versions {
+					versions[v.VersionID] = v
+				}
+			}
+		})
+		// the current registrations and versions are sent when enabling
+		if err := serviceworker.Enable().Do(ctx); err != nil {
+			return err
+		}
+		cancel()
+		if err := serviceworker.Disable().Do(ctx); err != nil {
+			return err
+		}
+
+		var workers []WorkerInfo
+		mu.Lock()
+		for _, v := range versions {
+			if v.Status == serviceworker.VersionStatusRedundant {
+				continue
+			}
+			controls := false
+			for _, id := range v.ControlledClients {
+				controls = controls || id == t.TargetID
+			}
+			scope, ok := scopes[v.RegistrationID]
+			if !controls && (!ok || !strings.HasPrefix(urlstr, scope)) {
+				continue
+			}
+			workers = append(workers, WorkerInfo{
+				Type:          "service_worker",
+				URL:           v.ScriptURL,
+				VersionID:     v.VersionID,
+				Status:        v.Status,
+				RunningStatus: v.RunningStatus,
+			})
+		}
+		mu.Unlock()
+
+		infos, err := Targets(ctx)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if (info.Type == "worker" || info.Type == "shared_worker") && sameOrigin(info.URL, urlstr) {
+				workers = append(workers, WorkerInfo{Type: info.Type, URL: info.URL})
+			}
+		}
+
+		sort.Slice(workers, func(i, j int) bool {
+			if workers[i].Type != workers[j].Type {
+				return workers[i].Type < workers[j].Type
+			}
+			if workers[i].URL != workers[j].URL {
+				return workers[i].URL < workers[j].URL
+			}
+			return workers[i].VersionID < workers[j].VersionID
+		})
+		*out = workers
+		return nil
+	})
+}
+
+// sameOrigin returns whether the two URLs have the same scheme and host.
+func sameOrigin(a, b string) bool {
+	u, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	v, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == v.Scheme && u.Host == v.Host
+}
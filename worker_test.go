@@ -0,0 +1,61 @@
+package chromedp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chromedp/cdproto/serviceworker"
+)
+
+func TestWorkers(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", writeHTML(`
+<script>
+	window.shared = new SharedWorker('shared.js');
+	window.ready = navigator.serviceWorker.register('sw.js').then(function() {
+		return navigator.serviceWorker.ready;
+	});
+</script>
+	`))
+	for _, name := range []string{"/shared.js", "/sw.js"} {
+		mux.HandleFunc(name, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/javascript")
+			io.WriteString(w, "self.onconnect = function() {};")
+		})
+	}
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	ctx, cancel := testAllocate(t, "")
+	defer cancel()
+
+	var ready bool
+	var workers []WorkerInfo
+	if err := Run(ctx,
+		Navigate(s.URL),
+		Evaluate(`window.ready.then(function() { return true; })`, &ready, evalAwaitPromise),
+		Workers(&workers),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var shared, service bool
+	for _, w := range workers {
+		switch {
+		case w.Type == "shared_worker" && w.URL == s.URL+"/shared.js":
+			shared = true
+		case w.Type == "service_worker" && w.URL == s.URL+"/sw.js":
+			service = w.Status == serviceworker.VersionStatusActivated && w.VersionID != ""
+		}
+	}
+	if !shared {
+		t.Errorf("expected the shared worker to be listed, got: %+v", workers)
+	}
+	if !service {
+		t.Errorf("expected the activated service worker to be listed, got: %+v", workers)
+	}
+}